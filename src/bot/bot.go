@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"disciplo/src/config"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Client is the subset of the Telegram Bot API the bot uses. *tgbotapi.BotAPI
+// implements it; bottest.Client is an in-memory fake.
+type Client interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetChat(config tgbotapi.ChatInfoConfig) (tgbotapi.Chat, error)
+	GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error)
+	GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error)
+	GetChatMembersCount(config tgbotapi.ChatMemberCountConfig) (int, error)
+}
+
+var _ Client = (*tgbotapi.BotAPI)(nil)
+
+// CommandHandler handles a bot command such as /start
+type CommandHandler func(b *Bot, message *tgbotapi.Message)
+
+// GroupCommandHandler handles a command sent in a community chat by one of the
+// community's group admins, as recorded in the database
+type GroupCommandHandler func(b *Bot, message *tgbotapi.Message, community, admin *core.Record)
+
+// Bot dispatches Telegram updates against the PocketBase app
+type Bot struct {
+	client   Client
+	self     tgbotapi.User
+	app      core.App
+	cfg      *config.Config
+	commands map[string]CommandHandler
+
+	groupCommands map[string]GroupCommandHandler
+}
+
+// New creates a bot with the default commands registered. self is the bot's own
+// Telegram user, as returned by getMe.
+func New(client Client, self tgbotapi.User, app core.App, cfg *config.Config) *Bot {
+	b := &Bot{
+		client:   client,
+		self:     self,
+		app:      app,
+		cfg:      cfg,
+		commands: make(map[string]CommandHandler),
+
+		groupCommands: make(map[string]GroupCommandHandler),
+	}
+
+	b.Handle("start", (*Bot).handleStart)
+	b.Handle("help", (*Bot).handleHelp)
+	b.Handle("status", (*Bot).handleStatus)
+
+	b.HandleGroup("members", (*Bot).handleMembers)
+	b.HandleGroup("promote", (*Bot).handlePromote)
+	b.HandleGroup("demote", (*Bot).handleDemote)
+	b.HandleGroup("transfer", (*Bot).handleTransfer)
+	b.HandleGroup("kick", (*Bot).handleKick)
+
+	return b
+}
+
+// Handle registers the handler for a command, replacing any existing one
+func (b *Bot) Handle(command string, handler CommandHandler) {
+	b.commands[command] = handler
+}
+
+// HandleGroup registers the handler for a group admin command, replacing any
+// existing one. The command only runs in a community chat, for its group admins.
+func (b *Bot) HandleGroup(command string, handler GroupCommandHandler) {
+	b.groupCommands[command] = handler
+}
+
+// HandleUpdate dispatches a Telegram update, whether it came from polling, the
+// webhook or a test
+func (b *Bot) HandleUpdate(update tgbotapi.Update) {
+	switch {
+	case update.ChatMember != nil:
+		b.handleChatMember(update.ChatMember)
+	case update.MyChatMember != nil:
+		b.handleMyChatMember(update.MyChatMember)
+	case update.CallbackQuery != nil:
+		b.handleCallbackQuery(update.CallbackQuery)
+	case update.ChatJoinRequest != nil:
+		b.handleJoinRequest(update.ChatJoinRequest)
+	case update.Message != nil && update.Message.MigrateToChatID != 0:
+		handleChatMigration(b.app, update.Message)
+	case update.Message != nil && update.Message.IsCommand():
+		b.handleCommand(update.Message)
+	}
+}
+
+// handleCommand routes a command message to its registered handler
+func (b *Bot) handleCommand(message *tgbotapi.Message) {
+	if handler, ok := b.groupCommands[message.Command()]; ok {
+		b.handleGroupCommand(message, handler)
+		return
+	}
+
+	handler, ok := b.commands[message.Command()]
+	if !ok {
+		b.client.Send(tgbotapi.NewMessage(message.Chat.ID, "Unknown command. Use /help for available commands."))
+		return
+	}
+
+	handler(b, message)
+}
+
+// handleGroupCommand runs a group admin command after checking that it was sent in
+// a community chat by a verified group admin of that community
+func (b *Bot) handleGroupCommand(message *tgbotapi.Message, handler GroupCommandHandler) {
+	if message.Chat.IsPrivate() {
+		b.client.Send(tgbotapi.NewMessage(message.Chat.ID, "This command only works in a community chat."))
+		return
+	}
+
+	community, err := FindCommunityByChat(b.app, message.Chat.ID)
+	if err != nil {
+		b.reply(message, "This chat isn't linked to a community.")
+		return
+	}
+
+	var admin *core.Record
+	if message.From != nil {
+		admin, _ = FindUserByTelegramID(b.app, message.From.ID)
+	}
+	if !IsGroupAdmin(admin, community) {
+		b.reply(message, fmt.Sprintf("Only the group admins of %s can use /%s.", community.GetString("name"), message.Command()))
+		return
+	}
+
+	handler(b, message, community, admin)
+}
+
+// IsGroupAdmin reports whether a user is an active group admin of a community
+func IsGroupAdmin(user, community *core.Record) bool {
+	return user != nil &&
+		user.GetString("group_admin") == community.Id &&
+		user.GetString("status") == "accepted" &&
+		user.GetBool("verified")
+}
+
+// reply answers a message in its chat, as a reply to it
+func (b *Bot) reply(message *tgbotapi.Message, text string) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := b.client.Send(msg); err != nil {
+		log.Printf("⚠️  Failed to reply in chat %d: %v", message.Chat.ID, err)
+	}
+}
@@ -0,0 +1,290 @@
+// Package bottest provides an in-memory Telegram client for exercising the bot
+// without network access.
+package bottest
+
+import (
+	"disciplo/src/bot"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var _ bot.Client = (*Client)(nil)
+
+// Self is the Telegram user the fake bot runs as
+var Self = tgbotapi.User{ID: 1000, IsBot: true, FirstName: "Disciplo", UserName: "disciplo_test_bot"}
+
+// Client is a fake bot.Client that records every call and keeps a minimal model of
+// chats and their members
+type Client struct {
+	mu sync.Mutex
+
+	// Chats returned by GetChat, keyed by chat ID
+	Chats map[int64]tgbotapi.Chat
+	// Members of each chat, keyed by chat ID then user ID
+	Members map[int64]map[int64]tgbotapi.ChatMember
+
+	sent      []tgbotapi.Chattable
+	requests  []tgbotapi.Chattable
+	messageID int
+	linkID    int
+}
+
+// NewClient returns an empty fake client
+func NewClient() *Client {
+	return &Client{
+		Chats:   make(map[int64]tgbotapi.Chat),
+		Members: make(map[int64]map[int64]tgbotapi.ChatMember),
+	}
+}
+
+// AddChat registers a group chat in which the bot is an administrator with all the
+// rights it needs
+func (c *Client) AddChat(chat tgbotapi.Chat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Chats[chat.ID] = chat
+	c.setMember(chat.ID, tgbotapi.ChatMember{
+		User:               &Self,
+		Status:             "administrator",
+		CanInviteUsers:     true,
+		CanRestrictMembers: true,
+		CanPromoteMembers:  true,
+	})
+}
+
+// SetMember sets a user's membership in a chat
+func (c *Client) SetMember(chatID int64, member tgbotapi.ChatMember) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setMember(chatID, member)
+}
+
+func (c *Client) setMember(chatID int64, member tgbotapi.ChatMember) {
+	if c.Members[chatID] == nil {
+		c.Members[chatID] = make(map[int64]tgbotapi.ChatMember)
+	}
+	c.Members[chatID][member.User.ID] = member
+}
+
+// Send records a message and returns it as delivered
+func (c *Client) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sent = append(c.sent, chattable)
+	c.messageID++
+
+	message := tgbotapi.Message{MessageID: c.messageID, Date: int(time.Now().Unix()), From: &Self}
+	switch config := chattable.(type) {
+	case tgbotapi.MessageConfig:
+		message.Chat = &tgbotapi.Chat{ID: config.ChatID}
+		message.Text = config.Text
+	case tgbotapi.EditMessageTextConfig:
+		message.MessageID = config.MessageID
+		message.Chat = &tgbotapi.Chat{ID: config.ChatID}
+		message.Text = config.Text
+	}
+
+	return message, nil
+}
+
+// Request records a call and applies its effect on the fake chats
+func (c *Client) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, chattable)
+
+	var result interface{} = true
+	switch config := chattable.(type) {
+	case tgbotapi.CreateChatInviteLinkConfig:
+		if _, ok := c.Chats[config.ChatID]; !ok {
+			return nil, chatNotFound(config.ChatID)
+		}
+		c.linkID++
+		result = tgbotapi.ChatInviteLink{
+			InviteLink:  fmt.Sprintf("https://t.me/+fake%d", c.linkID),
+			Creator:     Self,
+			Name:        config.Name,
+			ExpireDate:  config.ExpireDate,
+			MemberLimit: config.MemberLimit,
+		}
+	case tgbotapi.BanChatMemberConfig:
+		c.setMember(config.ChatID, tgbotapi.ChatMember{User: &tgbotapi.User{ID: config.UserID}, Status: "kicked"})
+	case tgbotapi.UnbanChatMemberConfig:
+		if member, ok := c.Members[config.ChatID][config.UserID]; ok && member.Status == "kicked" {
+			member.Status = "left"
+			c.setMember(config.ChatID, member)
+		}
+	case tgbotapi.ApproveChatJoinRequestConfig:
+		c.setMember(config.ChatID, tgbotapi.ChatMember{User: &tgbotapi.User{ID: config.UserID}, Status: "member"})
+	case tgbotapi.PromoteChatMemberConfig:
+		member := tgbotapi.ChatMember{
+			User:               &tgbotapi.User{ID: config.UserID},
+			Status:             "member",
+			CanManageChat:      config.CanManageChat,
+			CanDeleteMessages:  config.CanDeleteMessages,
+			CanInviteUsers:     config.CanInviteUsers,
+			CanRestrictMembers: config.CanRestrictMembers,
+			CanPinMessages:     config.CanPinMessages,
+			CanPromoteMembers:  config.CanPromoteMembers,
+		}
+		if config.CanManageChat || config.CanDeleteMessages || config.CanInviteUsers || config.CanRestrictMembers || config.CanPinMessages || config.CanPromoteMembers {
+			member.Status = "administrator"
+		}
+		c.setMember(config.ChatID, member)
+	case tgbotapi.LeaveChatConfig:
+		delete(c.Members[config.ChatID], Self.ID)
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &tgbotapi.APIResponse{Ok: true, Result: raw}, nil
+}
+
+// GetChat returns a chat registered with AddChat
+func (c *Client) GetChat(config tgbotapi.ChatInfoConfig) (tgbotapi.Chat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	chat, ok := c.Chats[config.ChatID]
+	if !ok {
+		return tgbotapi.Chat{}, chatNotFound(config.ChatID)
+	}
+	return chat, nil
+}
+
+// GetChatMember returns a user's membership, "left" when they were never added
+func (c *Client) GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Chats[config.ChatID]; !ok {
+		return tgbotapi.ChatMember{}, chatNotFound(config.ChatID)
+	}
+	if member, ok := c.Members[config.ChatID][config.UserID]; ok {
+		return member, nil
+	}
+	return tgbotapi.ChatMember{User: &tgbotapi.User{ID: config.UserID}, Status: "left"}, nil
+}
+
+// GetChatAdministrators returns the creator and administrators of a chat
+func (c *Client) GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Chats[config.ChatID]; !ok {
+		return nil, chatNotFound(config.ChatID)
+	}
+
+	var admins []tgbotapi.ChatMember
+	for _, member := range c.Members[config.ChatID] {
+		if member.Status == "creator" || member.Status == "administrator" {
+			admins = append(admins, member)
+		}
+	}
+	return admins, nil
+}
+
+// GetChatMembersCount counts the members currently in a chat
+func (c *Client) GetChatMembersCount(config tgbotapi.ChatMemberCountConfig) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Chats[config.ChatID]; !ok {
+		return 0, chatNotFound(config.ChatID)
+	}
+
+	count := 0
+	for _, member := range c.Members[config.ChatID] {
+		switch member.Status {
+		case "creator", "administrator", "member":
+			count++
+		case "restricted":
+			if member.IsMember {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// Sent returns every message sent so far
+func (c *Client) Sent() []tgbotapi.Chattable {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), c.sent...)
+}
+
+// Requests returns every request made so far
+func (c *Client) Requests() []tgbotapi.Chattable {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), c.requests...)
+}
+
+// MessagesTo returns the text of every message sent to a chat, in order
+func (c *Client) MessagesTo(chatID int64) []string {
+	var texts []string
+	for _, chattable := range c.Sent() {
+		if message, ok := chattable.(tgbotapi.MessageConfig); ok && message.ChatID == chatID {
+			texts = append(texts, message.Text)
+		}
+	}
+	return texts
+}
+
+// CommandUpdate builds the update for a private command message such as
+// "/start TOKEN" sent by a user
+func CommandUpdate(from tgbotapi.User, text string) tgbotapi.Update {
+	command := strings.SplitN(text, " ", 2)[0]
+	return tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			MessageID: 1,
+			From:      &from,
+			Chat:      &tgbotapi.Chat{ID: from.ID, Type: "private", FirstName: from.FirstName, UserName: from.UserName},
+			Date:      int(time.Now().Unix()),
+			Text:      text,
+			Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}},
+		},
+	}
+}
+
+// GroupCommandUpdate builds the update for a command such as "/promote @name" sent
+// by a user in a group chat, replying to a message from replyTo when it is not nil
+func GroupCommandUpdate(from tgbotapi.User, chat tgbotapi.Chat, text string, replyTo *tgbotapi.User) tgbotapi.Update {
+	update := CommandUpdate(from, text)
+	update.Message.Chat = &chat
+	if replyTo != nil {
+		update.Message.ReplyToMessage = &tgbotapi.Message{MessageID: 2, From: replyTo, Chat: &chat}
+	}
+	return update
+}
+
+// CallbackUpdate builds the update for a user tapping an inline keyboard button
+// with the given callback data on a message the bot sent them
+func CallbackUpdate(from tgbotapi.User, data string) tgbotapi.Update {
+	return tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      fmt.Sprintf("callback-%d", from.ID),
+			From:    &from,
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: from.ID, Type: "private"}},
+			Data:    data,
+		},
+	}
+}
+
+func chatNotFound(chatID int64) error {
+	return &tgbotapi.Error{Code: 400, Message: fmt.Sprintf("Bad Request: chat %d not found", chatID)}
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// handleStart links a Telegram account to the user owning the invitation token
+func (b *Bot) handleStart(message *tgbotapi.Message) {
+	args := message.CommandArguments()
+	templateName := "start_welcome.md"
+	var templateData interface{}
+	var linkedUser *core.Record
+	
+	if args != "" {
+		// Find user by telegram_token (works for both admin and regular users)
+		user, err := b.app.FindFirstRecordByFilter("users", "telegram_token = {:token}", map[string]interface{}{
+			"token": args,
+		})
+		
+		if err != nil || user == nil {
+			templateName = "start_invalid_token.md"
+			log.Printf("❌ Invalid token used: %s", args)
+		} else {
+			// Check token expiration (24 hours for regular users, 1 hour for fresh admin tokens)
+			tokenCreated := user.GetDateTime("telegram_token_created")
+			isAdmin := user.GetBool("admin")
+			
+			var tokenExpired bool
+			if isAdmin {
+				// Admin tokens expire after 1 hour
+				tokenExpired = time.Since(tokenCreated.Time()) > time.Hour
+			} else {
+				// User tokens expire after 24 hours  
+				tokenExpired = time.Since(tokenCreated.Time()) > 24*time.Hour
+			}
+			
+			if tokenExpired {
+				templateName = "start_expired_token.md"
+				log.Printf("❌ Expired token used: %s (created: %v)", args, tokenCreated)
+			} else {
+			
+			// Update user with Telegram information
+			user.Set("telegram_id", fmt.Sprintf("%d", message.From.ID))
+			user.Set("telegram_name", message.From.UserName)
+			user.Set("verified", true) // Now verified since Telegram is linked
+			user.Set("telegram_token", "") // Clear the token after successful linking
+			
+			if err := b.app.Save(user); err != nil {
+				log.Printf("❌ Failed to update user telegram info: %v", err)
+				templateName = "start_failed.md"
+			} else {
+				// Determine user role for message
+				isAdmin := user.GetBool("admin")
+				userName := user.GetString("name")
+				
+				templateName = "connection_success.md"
+				templateData = connectionSuccessData{
+					FirstName: message.From.FirstName,
+					IsAdmin:   isAdmin,
+				}
+				
+				linkedUser = user
+				log.Printf("🔗 USER LINKED - Name: %s | Email: %s | Admin: %v | TG_ID: %d | Username: @%s",
+					userName, user.GetString("email"), isAdmin, message.From.ID, message.From.UserName)
+			}
+		}
+		}
+	}
+
+	// Render the reply, with a dashboard link
+	dashboardURL := b.cfg.Host + "/dashboard"
+	msg := newTemplateMessage(message.Chat.ID, templateName, templateData, "Welcome to Disciplo! Use /help for available commands.")
+	if !strings.HasPrefix(b.cfg.Host, "https://") {
+		// For HTTP URLs, add dashboard link as text
+		if link, err := renderTemplate("dashboard_link.md", dashboardData{URL: dashboardURL}); err == nil && msg.ParseMode == tgbotapi.ModeMarkdownV2 {
+			msg.Text += "\n\n" + link
+		}
+	}
+
+	// Add inline keyboard for dashboard access only if HTTPS
+	if strings.HasPrefix(b.cfg.Host, "https://") {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonURL("🌐 Visit Dashboard", dashboardURL),
+			),
+		)
+		msg.ReplyMarkup = keyboard
+	}
+
+	b.client.Send(msg)
+
+	// Newly verified members get their community invite links right away
+	if linkedUser != nil && linkedUser.GetString("status") == "accepted" {
+		if err := b.provisionMemberships(linkedUser); err != nil {
+			log.Printf("⚠️  Failed to provision memberships for %s: %v", linkedUser.GetString("email"), err)
+		}
+	}
+}
+
+// handleHelp lists the available commands
+func (b *Bot) handleHelp(message *tgbotapi.Message) {
+	b.client.Send(newTemplateMessage(message.Chat.ID, "help.md", nil, "Available commands: /start, /help, /status"))
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// FindCommunityByChat returns the community bound to a Telegram chat
+func FindCommunityByChat(app core.App, chatID int64) (*core.Record, error) {
+	return app.FindFirstRecordByData("communities", "telegram_id", fmt.Sprintf("%d", chatID))
+}
+
+// FindUserByTelegramID returns the user linked to a Telegram account
+func FindUserByTelegramID(app core.App, telegramID int64) (*core.Record, error) {
+	return app.FindFirstRecordByData("users", "telegram_id", fmt.Sprintf("%d", telegramID))
+}
+
+// handleJoinRequest approves a join request to a managed community chat when it
+// comes from an accepted, verified member of that community and declines it otherwise
+func (b *Bot) handleJoinRequest(request *tgbotapi.ChatJoinRequest) {
+	community, err := FindCommunityByChat(b.app, request.Chat.ID)
+	if err != nil {
+		// Not one of our chats, leave it to the chat's own admins
+		return
+	}
+
+	user, _ := FindUserByTelegramID(b.app, request.From.ID)
+	reason := joinRefusalReason(user, community)
+
+	chat := tgbotapi.ChatConfig{ChatID: request.Chat.ID}
+	var decision tgbotapi.Chattable
+	if reason == "" {
+		decision = tgbotapi.ApproveChatJoinRequestConfig{ChatConfig: chat, UserID: request.From.ID}
+	} else {
+		decision = tgbotapi.DeclineChatJoinRequest{ChatConfig: chat, UserID: request.From.ID}
+	}
+
+	if _, err := b.client.Request(decision); err != nil {
+		log.Printf("⚠️  Failed to answer join request from %d to %s: %v", request.From.ID, community.GetString("name"), err)
+		return
+	}
+
+	if reason == "" {
+		log.Printf("✅ JOIN APPROVED - Community: %s | Email: %s | TG_ID: %d | Username: @%s",
+			community.GetString("name"), user.GetString("email"), request.From.ID, request.From.UserName)
+	} else {
+		log.Printf("🚫 JOIN DECLINED - Community: %s | TG_ID: %d | Username: @%s | Reason: %s",
+			community.GetString("name"), request.From.ID, request.From.UserName, reason)
+	}
+}
+
+// joinRefusalReason explains why a user may not join a community, or returns an
+// empty string when they may
+func joinRefusalReason(user, community *core.Record) string {
+	if reason := membershipRefusalReason(user, community); reason != "" {
+		return reason
+	}
+
+	for _, id := range user.GetStringSlice("groups") {
+		if id == community.Id {
+			return ""
+		}
+	}
+	return "not a member of this community"
+}
+
+// membershipRefusalReason explains why a user can't be a member of a community at
+// all, whether or not they were added to it, or returns an empty string when they can
+func membershipRefusalReason(user, community *core.Record) string {
+	switch {
+	case community.GetBool("archived"):
+		return "community archived"
+	case user == nil:
+		return "telegram account not linked"
+	case user.GetString("status") != "accepted":
+		return "member not accepted"
+	case !user.GetBool("verified"):
+		return "member not verified"
+	}
+	return ""
+}
@@ -0,0 +1,299 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// maxListedMembers caps the /members reply well under Telegram's message limit
+const maxListedMembers = 60
+
+// commandTarget is the Telegram user a group command is about, with the Disciplo
+// user linked to it when there is one
+type commandTarget struct {
+	telegramID int64
+	label      string
+	user       *core.Record
+}
+
+// resolveTarget finds who a group command is about: the author of the message it
+// replies to, or the member whose Telegram username is the first argument. It
+// returns the remaining arguments.
+func (b *Bot) resolveTarget(message *tgbotapi.Message) (*commandTarget, string, error) {
+	args := strings.TrimSpace(message.CommandArguments())
+
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+		target := &commandTarget{telegramID: reply.From.ID, label: reply.From.FirstName}
+		if reply.From.UserName != "" {
+			target.label = "@" + reply.From.UserName
+		}
+		target.user, _ = FindUserByTelegramID(b.app, reply.From.ID)
+		return target, args, nil
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "@") {
+		return nil, args, errors.New("reply to one of their messages or name them as @username")
+	}
+	username := strings.TrimPrefix(fields[0], "@")
+	user, err := b.app.FindFirstRecordByData("users", "telegram_name", username)
+	if err != nil || user.GetString("telegram_id") == "" {
+		return nil, args, fmt.Errorf("no member is linked to @%s, reply to one of their messages instead", username)
+	}
+
+	target := &commandTarget{telegramID: telegramChatID(user), label: "@" + username, user: user}
+	return target, strings.TrimSpace(strings.TrimPrefix(args, fields[0])), nil
+}
+
+// memberOfExpr matches users whose groups include the community
+func memberOfExpr(communityId string) dbx.Expression {
+	return dbx.NewExp(
+		"json_valid([[groups]]) AND EXISTS (SELECT 1 FROM json_each([[groups]]) WHERE json_each.value = {:community})",
+		dbx.Params{"community": communityId},
+	)
+}
+
+// handleMembers lists the community's members with their Disciplo status
+func (b *Bot) handleMembers(message *tgbotapi.Message, community, admin *core.Record) {
+	users := []*core.Record{}
+	err := b.app.RecordQuery("users").
+		AndWhere(dbx.Or(memberOfExpr(community.Id), dbx.HashExp{"group_admin": community.Id})).
+		OrderBy("name ASC").All(&users)
+	if err != nil {
+		log.Printf("⚠️  Failed to load the members of %s: %v", community.GetString("name"), err)
+		b.reply(message, "❌ Failed to load the members.")
+		return
+	}
+
+	lines := []string{fmt.Sprintf("👥 Members of %s (%d)", community.GetString("name"), len(users)), ""}
+	for i, user := range users {
+		if i == maxListedMembers {
+			lines = append(lines, fmt.Sprintf("…and %d more, see the community page.", len(users)-maxListedMembers))
+			break
+		}
+		lines = append(lines, memberLine(user, community))
+	}
+	if len(users) == 0 {
+		lines = append(lines, "No members yet.")
+	}
+	lines = append(lines, "", "👑 group admin · ✅ verified · ⏳ Telegram not linked · 🚫 no active membership")
+
+	b.reply(message, strings.Join(lines, "\n"))
+}
+
+// memberLine describes a member in the /members list
+func memberLine(user, community *core.Record) string {
+	name := user.GetString("name")
+	if username := user.GetString("telegram_name"); username != "" {
+		name += " (@" + username + ")"
+	}
+
+	switch {
+	case user.GetString("status") != "accepted":
+		return fmt.Sprintf("🚫 %s - %s", name, user.GetString("status"))
+	case user.GetString("group_admin") == community.Id:
+		return "👑 " + name
+	case !user.GetBool("verified"):
+		return "⏳ " + name
+	}
+	return "✅ " + name
+}
+
+// handlePromote makes a verified member of the community one of its group admins
+func (b *Bot) handlePromote(message *tgbotapi.Message, community, admin *core.Record) {
+	target, _, err := b.resolveTarget(message)
+	if err != nil {
+		b.reply(message, "Usage: /promote @username - "+err.Error()+".")
+		return
+	}
+
+	user := target.user
+	switch {
+	case user == nil:
+		b.reply(message, fmt.Sprintf("%s hasn't linked a Disciplo account.", target.label))
+		return
+	case joinRefusalReason(user, community) != "":
+		b.reply(message, fmt.Sprintf("%s can't be promoted: %s.", target.label, joinRefusalReason(user, community)))
+		return
+	case user.GetString("group_admin") == community.Id:
+		b.reply(message, fmt.Sprintf("%s is already a group admin here.", target.label))
+		return
+	case user.GetString("group_admin") != "":
+		b.reply(message, fmt.Sprintf("%s is already group admin of another community.", target.label))
+		return
+	}
+
+	user.Set("group_admin", community.Id)
+	user.Set("group_admin_since", types.NowDateTime())
+	if err := b.app.Save(user); err != nil {
+		log.Printf("⚠️  Failed to promote %s: %v", user.GetString("email"), err)
+		b.reply(message, "❌ Failed to save the promotion.")
+		return
+	}
+
+	result := fmt.Sprintf("👑 %s is now a group admin of %s.", target.label, community.GetString("name"))
+	if err := b.SetChatAdmin(community, user, true); err != nil {
+		log.Printf("⚠️  Failed to promote %s in %s: %v", user.GetString("email"), community.GetString("name"), err)
+		result += "\n\n⚠️ I couldn't make them a chat administrator; check that I have the \"Add new admins\" right."
+	}
+
+	log.Printf("👑 ADMIN PROMOTED - Community: %s | Email: %s | By: %s", community.GetString("name"), user.GetString("email"), admin.GetString("email"))
+	b.reply(message, result)
+}
+
+// handleDemote removes a group admin of the community, leaving them a regular member
+func (b *Bot) handleDemote(message *tgbotapi.Message, community, admin *core.Record) {
+	target, _, err := b.resolveTarget(message)
+	if err != nil {
+		b.reply(message, "Usage: /demote @username - "+err.Error()+".")
+		return
+	}
+
+	user := target.user
+	if user == nil || user.GetString("group_admin") != community.Id {
+		b.reply(message, fmt.Sprintf("%s isn't a group admin here.", target.label))
+		return
+	}
+	if user.Id == admin.Id {
+		admins, err := FindGroupAdmins(b.app, community)
+		if err != nil {
+			log.Printf("⚠️  Failed to load the group admins of %s: %v", community.GetString("name"), err)
+			b.reply(message, "❌ Failed to load the group admins, please try again.")
+			return
+		}
+		if len(admins) < 2 {
+			b.reply(message, "You are the only group admin here; /promote someone else before stepping down.")
+			return
+		}
+	}
+
+	user.Set("group_admin", "")
+	user.Set("group_admin_since", "")
+	if err := b.app.Save(user); err != nil {
+		log.Printf("⚠️  Failed to demote %s: %v", user.GetString("email"), err)
+		b.reply(message, "❌ Failed to save the demotion.")
+		return
+	}
+
+	result := fmt.Sprintf("%s is no longer a group admin of %s.", target.label, community.GetString("name"))
+	if err := b.SetChatAdmin(community, user, false); err != nil {
+		log.Printf("⚠️  Failed to demote %s in %s: %v", user.GetString("email"), community.GetString("name"), err)
+		result += "\n\n⚠️ I couldn't remove their chat administrator rights; Telegram only lets me demote admins I promoted."
+	}
+
+	log.Printf("⬇️  ADMIN DEMOTED - Community: %s | Email: %s | By: %s", community.GetString("name"), user.GetString("email"), admin.GetString("email"))
+	b.reply(message, result)
+}
+
+// handleTransfer moves a member from this community to another one: they are
+// removed from this chat and DMed an invite link to the other community's chat
+func (b *Bot) handleTransfer(message *tgbotapi.Message, community, admin *core.Record) {
+	target, args, err := b.resolveTarget(message)
+	if err != nil || args == "" {
+		usage := "Usage: /transfer @username Community name"
+		if err != nil {
+			usage += " - " + err.Error()
+		}
+		b.reply(message, usage+".")
+		return
+	}
+
+	user := target.user
+	if user == nil || !slices.Contains(user.GetStringSlice("groups"), community.Id) {
+		b.reply(message, fmt.Sprintf("%s isn't a member of %s.", target.label, community.GetString("name")))
+		return
+	}
+
+	destination, err := findCommunityByName(b.app, args)
+	if err != nil {
+		b.reply(message, fmt.Sprintf("There is no active community named %q.", args))
+		return
+	}
+	if destination.Id == community.Id {
+		b.reply(message, fmt.Sprintf("%s is already in %s.", target.label, community.GetString("name")))
+		return
+	}
+
+	groups := removeValue(user.GetStringSlice("groups"), community.Id)
+	user.Set("groups", appendUnique(groups, destination.Id))
+	wasGroupAdmin := user.GetString("group_admin") == community.Id
+	if wasGroupAdmin {
+		user.Set("group_admin", "")
+		user.Set("group_admin_since", "")
+	}
+	if err := b.app.Save(user); err != nil {
+		log.Printf("⚠️  Failed to transfer %s: %v", user.GetString("email"), err)
+		b.reply(message, "❌ Failed to save the transfer.")
+		return
+	}
+
+	log.Printf("🔀 MEMBER TRANSFERRED - Email: %s | From: %s | To: %s | By: %s",
+		user.GetString("email"), community.GetString("name"), destination.GetString("name"), admin.GetString("email"))
+	// Telegram won't kick an administrator, so take their chat rights away first
+	if wasGroupAdmin {
+		if err := b.SetChatAdmin(community, user, false); err != nil {
+			log.Printf("⚠️  Failed to demote %s in %s: %v", user.GetString("email"), community.GetString("name"), err)
+		}
+	}
+	b.RemoveMember(user, community)
+
+	result := fmt.Sprintf("🔀 %s moved to %s.", target.label, destination.GetString("name"))
+	if user.GetBool("verified") && user.GetString("telegram_id") != "" && destination.GetString("telegram_id") != "" {
+		if err := b.InviteMember(user, destination); err != nil {
+			log.Printf("⚠️  Failed to invite %s to %s: %v", user.GetString("email"), destination.GetString("name"), err)
+			result += " I couldn't send them an invite link to its chat: " + err.Error()
+		} else {
+			result += " I've sent them an invite link to its chat."
+		}
+	}
+	b.reply(message, result)
+}
+
+// handleKick removes someone from the chat who has no active membership of the
+// community: unlinked Telegram accounts, members who aren't verified or accepted,
+// and members of other communities
+func (b *Bot) handleKick(message *tgbotapi.Message, community, admin *core.Record) {
+	target, _, err := b.resolveTarget(message)
+	if err != nil {
+		b.reply(message, "Usage: /kick - reply to one of their messages. "+err.Error()+".")
+		return
+	}
+
+	if target.telegramID == b.self.ID {
+		return
+	}
+	reason := joinRefusalReason(target.user, community)
+	if reason == "" {
+		b.reply(message, fmt.Sprintf("%s is an active member of %s. Remove them from the community on the admin pages instead.", target.label, community.GetString("name")))
+		return
+	}
+
+	if err := b.kickFromChat(target.user, target.telegramID, community); err != nil {
+		b.reply(message, fmt.Sprintf("❌ Couldn't remove %s: %s.", target.label, err))
+		return
+	}
+	log.Printf("👢 KICK COMMAND - Community: %s | TG_ID: %d | Reason: %s | By: %s", community.GetString("name"), target.telegramID, reason, admin.GetString("email"))
+	b.reply(message, fmt.Sprintf("👢 Removed %s (%s).", target.label, reason))
+}
+
+// findCommunityByName returns the active community with the given name, ignoring case
+func findCommunityByName(app core.App, name string) (*core.Record, error) {
+	communities, err := app.FindAllRecords("communities", dbx.HashExp{"archived": false})
+	if err != nil {
+		return nil, err
+	}
+	for _, community := range communities {
+		if strings.EqualFold(community.GetString("name"), strings.TrimSpace(name)) {
+			return community, nil
+		}
+	}
+	return nil, fmt.Errorf("community %q not found", name)
+}
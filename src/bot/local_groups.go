@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// LocalAssignment is the change to a user's local communities that their location
+// calls for
+type LocalAssignment struct {
+	User   *core.Record
+	Add    []*core.Record
+	Remove []*core.Record
+}
+
+// LocalCommunities returns the active local communities covering a location, or
+// the fallback community when none does
+func LocalCommunities(app core.App, location, fallback string) ([]*core.Record, error) {
+	if location != "" {
+		communities, err := app.FindAllRecords("communities",
+			dbx.HashExp{"type": "local", "archived": false},
+			dbx.NewExp("json_valid([[location]]) AND EXISTS (SELECT 1 FROM json_each([[location]]) WHERE json_each.value = {:location})",
+				dbx.Params{"location": location}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(communities) > 0 {
+			return communities, nil
+		}
+	}
+
+	if fallback == "" {
+		return nil, nil
+	}
+	community, err := app.FindFirstRecordByFilter("communities", "name = {:name} && archived = false", dbx.Params{"name": fallback})
+	if err != nil {
+		return nil, fmt.Errorf("local fallback community %q not found", fallback)
+	}
+	return []*core.Record{community}, nil
+}
+
+// PlanLocalAssignment works out which local communities an accepted user should
+// join and leave so that they are in exactly the ones covering their location, or
+// the fallback when none does. Other users are left alone: losing access is
+// handled by the removal hooks.
+func PlanLocalAssignment(app core.App, user *core.Record, fallback string) (*LocalAssignment, error) {
+	plan := &LocalAssignment{User: user}
+	if user.GetString("status") != "accepted" {
+		return plan, nil
+	}
+
+	target, err := LocalCommunities(app, user.GetString("location"), fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := user.GetStringSlice("groups")
+	wanted := make(map[string]bool, len(target))
+	for _, community := range target {
+		wanted[community.Id] = true
+		if !slices.Contains(groups, community.Id) {
+			plan.Add = append(plan.Add, community)
+		}
+	}
+
+	if len(groups) == 0 {
+		return plan, nil
+	}
+	current, err := app.FindAllRecords("communities", dbx.In("id", toInterfaces(groups)...))
+	if err != nil {
+		return nil, err
+	}
+	for _, community := range current {
+		managed := community.GetString("type") == "local" || (fallback != "" && community.GetString("name") == fallback)
+		if managed && !wanted[community.Id] && !community.GetBool("archived") {
+			plan.Remove = append(plan.Remove, community)
+		}
+	}
+
+	return plan, nil
+}
+
+// Empty reports whether the assignment changes nothing
+func (a *LocalAssignment) Empty() bool {
+	return len(a.Add) == 0 && len(a.Remove) == 0
+}
+
+// Apply updates the user's groups; the caller saves the user
+func (a *LocalAssignment) Apply() {
+	groups := a.User.GetStringSlice("groups")
+	for _, community := range a.Remove {
+		groups = removeValue(groups, community.Id)
+	}
+	for _, community := range a.Add {
+		groups = appendUnique(groups, community.Id)
+	}
+	a.User.Set("groups", groups)
+}
+
+// SyncLocalAssignment mirrors an applied assignment on Telegram: verified members
+// get invite links to the chats they joined and are removed from the ones they left
+func (b *Bot) SyncLocalAssignment(a *LocalAssignment) {
+	for _, community := range a.Remove {
+		if community.GetString("telegram_id") != "" {
+			b.RemoveMember(a.User, community)
+		}
+	}
+
+	if len(a.Add) > 0 && a.User.GetBool("verified") {
+		if err := b.sendInvites(a.User, a.Add); err != nil {
+			log.Printf("⚠️  Failed to invite %s to their local communities: %v", a.User.GetString("email"), err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// markdownReserved are the characters MarkdownV2 requires to be escaped wherever
+// they don't start or end an entity
+const markdownReserved = "_*[]()~`>#+-=|{}.!"
+
+// validateMarkdownV2 reports the first construct Telegram would reject when
+// parsing text as MarkdownV2: unescaped reserved characters and entities that are
+// not closed or are closed out of order
+func validateMarkdownV2(text string) error {
+	runes := []rune(text)
+	var open []string // Formatting entities in the order they were opened
+	inLinkText := false
+	lineStart := true
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		atLineStart := lineStart
+		lineStart = r == '\n'
+
+		switch r {
+		case '\\':
+			if i+1 >= len(runes) {
+				return markdownError(runes, i, "trailing backslash")
+			}
+			i++
+		case '`':
+			end, err := skipCode(runes, i)
+			if err != nil {
+				return err
+			}
+			i = end
+		case '*', '_', '~', '|':
+			marker := string(r)
+			if (r == '_' || r == '|') && i+1 < len(runes) && runes[i+1] == r {
+				marker += string(r)
+				i++
+			} else if r == '|' {
+				return markdownError(runes, i, "unescaped '|'")
+			}
+			if n := len(open); n > 0 && open[n-1] == marker {
+				open = open[:n-1]
+			} else if containsString(open, marker) {
+				return markdownError(runes, i, fmt.Sprintf("%q closed out of order", marker))
+			} else {
+				open = append(open, marker)
+			}
+		case '[':
+			if inLinkText {
+				return markdownError(runes, i, "unescaped '[' inside a link")
+			}
+			inLinkText = true
+		case ']':
+			if !inLinkText {
+				return markdownError(runes, i, "unescaped ']'")
+			}
+			inLinkText = false
+			if i+1 >= len(runes) || runes[i+1] != '(' {
+				return markdownError(runes, i, "link without a URL")
+			}
+			end, err := skipURL(runes, i+1)
+			if err != nil {
+				return err
+			}
+			i = end
+		case '>':
+			if !atLineStart {
+				return markdownError(runes, i, "unescaped '>'")
+			}
+		default:
+			if strings.ContainsRune(markdownReserved, r) {
+				return markdownError(runes, i, fmt.Sprintf("unescaped %q", r))
+			}
+		}
+	}
+
+	if inLinkText {
+		return fmt.Errorf("invalid MarkdownV2: unclosed link")
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("invalid MarkdownV2: unclosed %q", open[len(open)-1])
+	}
+	return nil
+}
+
+// skipCode returns the index of the backtick closing the code span or pre block
+// opened at start. Only '`' and '\' are escaped inside them.
+func skipCode(runes []rune, start int) (int, error) {
+	fence := "`"
+	if strings.HasPrefix(string(runes[start:min(start+3, len(runes))]), "```") {
+		fence = "```"
+	}
+
+	for i := start + len(fence); i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\':
+			i++
+		case strings.HasPrefix(string(runes[i:min(i+len(fence), len(runes))]), fence):
+			return i + len(fence) - 1, nil
+		}
+	}
+	return 0, markdownError(runes, start, "unclosed code")
+}
+
+// skipURL returns the index of the ')' closing the link URL opened at start. Only
+// ')' and '\' are escaped inside it.
+func skipURL(runes []rune, start int) (int, error) {
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case ')':
+			return i, nil
+		}
+	}
+	return 0, markdownError(runes, start, "unclosed link URL")
+}
+
+func markdownError(runes []rune, at int, problem string) error {
+	line := strings.Count(string(runes[:at]), "\n") + 1
+	return fmt.Errorf("invalid MarkdownV2 on line %d: %s", line, problem)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// inviteLinkTTL is how long an issued invite link stays usable
+const inviteLinkTTL = 7 * 24 * time.Hour
+
+// MemberCommunities returns every active community a user belongs to: the ones
+// already in their groups, which include their local communities, and all default
+// communities
+func MemberCommunities(app core.App, user *core.Record) ([]*core.Record, error) {
+	filter := dbx.Or(
+		dbx.HashExp{"type": "default"},
+		dbx.In("id", toInterfaces(user.GetStringSlice("groups"))...),
+	)
+
+	return app.FindAllRecords("communities", filter, dbx.HashExp{"archived": false})
+}
+
+// provisionMemberships adds a newly verified user to their communities, issues a
+// single-use invite link for every community with a Telegram chat and DMs them
+func (b *Bot) provisionMemberships(user *core.Record) error {
+	communities, err := MemberCommunities(b.app, user)
+	if err != nil {
+		return fmt.Errorf("failed to find communities: %w", err)
+	}
+
+	groups := user.GetStringSlice("groups")
+	for _, community := range communities {
+		groups = appendUnique(groups, community.Id)
+	}
+	user.Set("groups", groups)
+	if err := b.app.Save(user); err != nil {
+		return fmt.Errorf("failed to save user groups: %w", err)
+	}
+
+	return b.sendInvites(user, communities)
+}
+
+// InviteMember DMs a member the invite link of a community an admin added them to.
+// It does nothing for communities without a chat or members without Telegram.
+func (b *Bot) InviteMember(user, community *core.Record) error {
+	if user.GetString("telegram_id") == "" {
+		return nil
+	}
+	return b.sendInvites(user, []*core.Record{community})
+}
+
+// sendInvites issues a single-use invite link for each community with a Telegram
+// chat and DMs them to the user as a keyboard. It fails when none of the links
+// could be issued.
+func (b *Bot) sendInvites(user *core.Record, communities []*core.Record) error {
+	chatID, err := strconv.ParseInt(user.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("user %s has no valid telegram_id", user.Id)
+	}
+
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	var linkErr error
+	for _, community := range communities {
+		if community.GetString("telegram_id") == "" {
+			continue
+		}
+
+		link, err := b.issueInviteLink(user, community)
+		if err != nil {
+			log.Printf("⚠️  Failed to create invite link for %s in %s: %v", user.GetString("email"), community.GetString("name"), err)
+			linkErr = err
+			continue
+		}
+
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(community.GetString("name"), link),
+		))
+	}
+
+	if len(buttons) == 0 {
+		if linkErr != nil {
+			return fmt.Errorf("failed to create invite links: %w", linkErr)
+		}
+		return nil
+	}
+
+	msg := newTemplateMessage(chatID, "group_invites.md", groupInvitesData{
+		Name:  user.GetString("name"),
+		Count: len(buttons),
+		TTL:   int(inviteLinkTTL.Hours() / 24),
+	}, "🔑 Your Community Groups\n\nTap below to join your groups. Each link works once, only for you.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
+	if _, err := b.client.Send(msg); err != nil {
+		return fmt.Errorf("failed to send invite links: %w", err)
+	}
+
+	log.Printf("🔑 INVITES SENT - Email: %s | Groups: %d", user.GetString("email"), len(buttons))
+	return nil
+}
+
+// issueInviteLink returns the user's outstanding link for a community, creating a
+// new one when there is none or it has expired
+func (b *Bot) issueInviteLink(user, community *core.Record) (string, error) {
+	existing, err := b.app.FindFirstRecordByFilter("invite_links",
+		"user = {:user} && community = {:community} && status = 'issued' && expires_at > {:now}",
+		dbx.Params{"user": user.Id, "community": community.Id, "now": types.NowDateTime()})
+	if err == nil {
+		return existing.GetString("link"), nil
+	}
+
+	chatID, err := strconv.ParseInt(community.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid chat id %q", community.GetString("telegram_id"))
+	}
+
+	expiresAt := time.Now().Add(inviteLinkTTL)
+	resp, err := b.client.Request(tgbotapi.CreateChatInviteLinkConfig{
+		ChatConfig:  tgbotapi.ChatConfig{ChatID: chatID},
+		Name:        "disciplo:" + user.Id,
+		ExpireDate:  int(expiresAt.Unix()),
+		MemberLimit: 1,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var invite tgbotapi.ChatInviteLink
+	if err := json.Unmarshal(resp.Result, &invite); err != nil {
+		return "", fmt.Errorf("unexpected createChatInviteLink response: %w", err)
+	}
+
+	collection, err := b.app.FindCollectionByNameOrId("invite_links")
+	if err != nil {
+		return "", err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", user.Id)
+	record.Set("community", community.Id)
+	record.Set("link", invite.InviteLink)
+	record.Set("status", "issued")
+	record.Set("expires_at", expiresAt)
+	if err := b.app.Save(record); err != nil {
+		return "", fmt.Errorf("failed to record invite link: %w", err)
+	}
+
+	return invite.InviteLink, nil
+}
+
+// recordInviteUsage marks an issued invite link as consumed when someone joins a
+// chat through it
+func recordInviteUsage(app core.App, update *tgbotapi.ChatMemberUpdated) {
+	if update.InviteLink == nil || !isInChat(update.NewChatMember) || isInChat(update.OldChatMember) {
+		return
+	}
+
+	record, err := app.FindFirstRecordByData("invite_links", "link", update.InviteLink.InviteLink)
+	if err != nil {
+		return
+	}
+
+	record.Set("status", "consumed")
+	record.Set("consumed_at", time.Unix(int64(update.Date), 0))
+	if err := app.Save(record); err != nil {
+		log.Printf("⚠️  Failed to mark invite link consumed: %v", err)
+		return
+	}
+
+	log.Printf("✅ INVITE USED - TG_ID: %d | Chat: %s", update.NewChatMember.User.ID, update.Chat.Title)
+}
+
+// isInChat reports whether a chat member is currently part of the chat
+func isInChat(member tgbotapi.ChatMember) bool {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	}
+	return false
+}
+
+func removeValue(values []string, value string) []string {
+	result := values[:0:0]
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
@@ -0,0 +1,262 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Callback data prefixes for the chat registration keyboard
+const (
+	callbackBindChat   = "bind"
+	callbackCreateChat = "create"
+	callbackLeaveChat  = "leave"
+)
+
+// offerChatRegistration is called when the bot joins a chat that isn't linked to any
+// community. If a verified admin added it, they get a DM to bind the chat to a
+// community; otherwise the bot leaves.
+func (b *Bot) offerChatRegistration(update *tgbotapi.ChatMemberUpdated) {
+	if update.Chat.IsPrivate() || isInChat(update.OldChatMember) || !isInChat(update.NewChatMember) {
+		return
+	}
+
+	admin, err := FindUserByTelegramID(b.app, update.From.ID)
+	if err != nil || !admin.GetBool("admin") || !admin.GetBool("verified") {
+		log.Printf("🚫 CHAT REFUSED - Chat: %s (%d) | Added by TG_ID: %d | Reason: not a verified admin", update.Chat.Title, update.Chat.ID, update.From.ID)
+		if _, err := b.client.Request(tgbotapi.LeaveChatConfig{ChatID: update.Chat.ID}); err != nil {
+			log.Printf("⚠️  Failed to leave chat %d: %v", update.Chat.ID, err)
+		}
+		return
+	}
+
+	communities, err := b.app.FindAllRecords("communities", dbx.HashExp{"telegram_id": "", "archived": false})
+	if err != nil {
+		log.Printf("⚠️  Failed to load communities: %v", err)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, community := range communities {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔗 "+community.GetString("name"),
+				fmt.Sprintf("%s:%d:%s", callbackBindChat, update.Chat.ID, community.Id)),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("➕ Create \""+update.Chat.Title+"\"",
+			fmt.Sprintf("%s:%d", callbackCreateChat, update.Chat.ID))),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🚪 Leave this chat",
+			fmt.Sprintf("%s:%d", callbackLeaveChat, update.Chat.ID))),
+	)
+
+	msg := tgbotapi.NewMessage(update.From.ID, fmt.Sprintf(
+		"👋 You added me to \"%s\".\n\nWhich community should this chat belong to?", update.Chat.Title))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.client.Send(msg); err != nil {
+		log.Printf("⚠️  Failed to offer chat registration to %s: %v", admin.GetString("email"), err)
+		return
+	}
+
+	log.Printf("📥 CHAT ADDED - Chat: %s (%d) | By: %s", update.Chat.Title, update.Chat.ID, admin.GetString("email"))
+}
+
+// handleCallbackQuery answers the buttons of the chat registration keyboard, and
+// hands rotation proposal buttons over to handleRotationCallback
+func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	if strings.HasPrefix(query.Data, callbackRotation+":") {
+		b.handleRotationCallback(query)
+		return
+	}
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) < 2 || query.Message == nil {
+		return
+	}
+
+	chatID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	admin, err := FindUserByTelegramID(b.app, query.From.ID)
+	if err != nil || !admin.GetBool("admin") || !admin.GetBool("verified") {
+		b.client.Request(tgbotapi.NewCallback(query.ID, "Only verified admins can do this."))
+		return
+	}
+
+	var result string
+	var bindErr error
+	switch parts[0] {
+	case callbackBindChat:
+		if len(parts) != 3 {
+			return
+		}
+		community, err := b.app.FindRecordById("communities", parts[2])
+		if err != nil {
+			result = "❌ That community no longer exists."
+			break
+		}
+		result, bindErr = b.bindChat(chatID, community, admin)
+	case callbackCreateChat:
+		collection, err := b.app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			result = "❌ Failed to create the community."
+			break
+		}
+		community := core.NewRecord(collection)
+		community.Set("type", "special")
+		result, bindErr = b.bindChat(chatID, community, admin)
+	case callbackLeaveChat:
+		if _, err := b.client.Request(tgbotapi.LeaveChatConfig{ChatID: chatID}); err != nil {
+			result = fmt.Sprintf("❌ Failed to leave the chat: %v", err)
+		} else {
+			result = "🚪 I left the chat."
+			log.Printf("🚪 CHAT LEFT - Chat: %d | By: %s", chatID, admin.GetString("email"))
+		}
+	default:
+		return
+	}
+
+	if bindErr != nil {
+		log.Printf("⚠️  CHAT BIND REFUSED - Chat: %d | By: %s | Reason: %v", chatID, admin.GetString("email"), bindErr)
+		b.client.Request(tgbotapi.NewCallback(query.ID, "❌ "+bindErr.Error()))
+		b.client.Send(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, "❌ "+bindErr.Error()))
+		return
+	}
+
+	b.client.Request(tgbotapi.NewCallback(query.ID, ""))
+	b.client.Send(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, result))
+}
+
+// bindChat links a Telegram chat to a community (saving it, so new communities are
+// created) and reports which admin rights the bot is still missing there. It fails
+// when the chat or the community got linked since the buttons were sent.
+func (b *Bot) bindChat(chatID int64, community *core.Record, admin *core.Record) (string, error) {
+	existing, err := b.app.FindFirstRecordByFilter("communities",
+		"telegram_id = {:chat} || (id = {:community} && telegram_id != '')",
+		dbx.Params{"chat": fmt.Sprintf("%d", chatID), "community": community.Id})
+	switch {
+	case err == nil && existing.GetString("telegram_id") == fmt.Sprintf("%d", chatID):
+		return "", fmt.Errorf("this chat is already linked to \"%s\"", existing.GetString("name"))
+	case err == nil:
+		return "", fmt.Errorf("\"%s\" is already linked to another chat", existing.GetString("name"))
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", fmt.Errorf("failed to check the existing links: %w", err)
+	}
+
+	created := community.IsNew()
+	chat, err := b.linkChat(community, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("🔗 CHAT BOUND - Community: %s | Chat: %s (%d) | By: %s", community.GetString("name"), chat.Title, chat.ID, admin.GetString("email"))
+
+	result := fmt.Sprintf("✅ \"%s\" is now linked to the community \"%s\".", chat.Title, community.GetString("name"))
+	if created {
+		result += "\n\nThe new community was created as \"special\"; change its type from the admin panel if needed."
+	}
+
+	if missing := b.missingAdminRights(chatID); len(missing) > 0 {
+		result += "\n\n⚠️ Please make me an administrator with these rights: " + strings.Join(missing, ", ") + "."
+	} else {
+		result += "\n\n👮 I have all the admin rights I need."
+	}
+
+	return result, nil
+}
+
+// LinkChat links a community to a Telegram chat the bot is in, as done from the
+// admin pages, and returns the admin rights the bot still lacks in that chat
+func (b *Bot) LinkChat(community *core.Record, chatID int64) ([]string, error) {
+	if existing, err := FindCommunityByChat(b.app, chatID); err == nil && existing.Id != community.Id {
+		return nil, fmt.Errorf("the chat is already linked to %q", existing.GetString("name"))
+	}
+
+	chat, err := b.linkChat(community, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔗 CHAT BOUND - Community: %s | Chat: %s (%d)", community.GetString("name"), chat.Title, chat.ID)
+	return b.missingAdminRights(chatID), nil
+}
+
+// linkChat stores a chat's id, username and type on a community, naming the
+// community after the chat when it has no name yet
+func (b *Bot) linkChat(community *core.Record, chatID int64) (tgbotapi.Chat, error) {
+	chat, err := b.client.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil {
+		return chat, fmt.Errorf("I can't access that chat: %w", err)
+	}
+
+	if community.GetString("name") == "" {
+		community.Set("name", chat.Title)
+	}
+	community.Set("telegram_id", fmt.Sprintf("%d", chat.ID))
+	community.Set("telegram_username", chat.UserName)
+	community.Set("telegram_type", chat.Type)
+	if err := b.app.Save(community); err != nil {
+		return chat, fmt.Errorf("failed to save the community: %w", err)
+	}
+
+	b.refreshMemberCount(community)
+	return chat, nil
+}
+
+// missingAdminRights lists the rights the bot needs in a community chat but lacks
+func (b *Bot) missingAdminRights(chatID int64) []string {
+	member, err := b.client.GetChatMember(tgbotapi.GetChatMemberConfig{ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: b.self.ID}})
+	if err != nil {
+		return []string{"administrator"}
+	}
+
+	if member.IsCreator() {
+		return nil
+	}
+	if !member.IsAdministrator() {
+		return []string{"Invite users", "Ban users", "Add new admins"}
+	}
+
+	var missing []string
+	if !member.CanInviteUsers {
+		missing = append(missing, "Invite users")
+	}
+	if !member.CanRestrictMembers {
+		missing = append(missing, "Ban users")
+	}
+	if !member.CanPromoteMembers {
+		missing = append(missing, "Add new admins")
+	}
+	return missing
+}
+
+// handleChatMigration follows a group being upgraded to a supergroup, which gives
+// the chat a new ID
+func handleChatMigration(app core.App, message *tgbotapi.Message) {
+	if message.MigrateToChatID == 0 {
+		return
+	}
+
+	community, err := FindCommunityByChat(app, message.Chat.ID)
+	if err != nil {
+		return
+	}
+
+	community.Set("telegram_id", fmt.Sprintf("%d", message.MigrateToChatID))
+	community.Set("telegram_type", "supergroup")
+	if err := app.Save(community); err != nil {
+		log.Printf("⚠️  Failed to follow chat migration for %s: %v", community.GetString("name"), err)
+		return
+	}
+
+	log.Printf("🔀 CHAT MIGRATED - Community: %s | %d -> %d", community.GetString("name"), message.Chat.ID, message.MigrateToChatID)
+}
@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// outstandingLink is an issued invite link captured before its user is deleted,
+// since the invite_links records are cascade-deleted along with the user
+type outstandingLink struct {
+	link      string
+	chatID    int64
+	community string
+}
+
+// registerRemovalHooks removes members from every managed community chat when their
+// status leaves accepted, they are no longer verified, or their record is deleted.
+// The hooks are bound at setup, before the bot connects; changes made while no bot
+// is running are logged and left for the next reconciliation.
+func registerRemovalHooks(app core.App) {
+	var pendingDeletes sync.Map
+
+	app.OnRecordAfterUpdateSuccess("users").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		lostAccess := (original.GetString("status") == "accepted" && e.Record.GetString("status") != "accepted") ||
+			(original.GetBool("verified") && !e.Record.GetBool("verified"))
+
+		if lostAccess {
+			user := e.Record.Fresh()
+			removeFromChatsLater(user, findOutstandingLinks(e.App, user.Id))
+		}
+
+		return e.Next()
+	})
+
+	app.OnRecordDelete("users").BindFunc(func(e *core.RecordEvent) error {
+		pendingDeletes.Store(e.Record.Id, findOutstandingLinks(e.App, e.Record.Id))
+		return e.Next()
+	})
+
+	app.OnRecordAfterDeleteSuccess("users").BindFunc(func(e *core.RecordEvent) error {
+		if links, ok := pendingDeletes.LoadAndDelete(e.Record.Id); ok {
+			removeFromChatsLater(e.Record.Fresh(), links.([]outstandingLink))
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAfterDeleteError("users").BindFunc(func(e *core.RecordErrorEvent) error {
+		pendingDeletes.Delete(e.Record.Id)
+		return e.Next()
+	})
+}
+
+// removeFromChatsLater removes the user from the community chats in the background
+// through the running bot, or logs that it couldn't when there is none
+func removeFromChatsLater(user *core.Record, links []outstandingLink) {
+	b := Running()
+	if b == nil {
+		log.Printf("⚠️  CHAT REMOVAL SKIPPED - Email: %s | Bot not running", user.GetString("email"))
+		return
+	}
+	go b.removeFromChats(user, links)
+}
+
+// findOutstandingLinks returns the user's issued, not yet used invite links
+func findOutstandingLinks(app core.App, userId string, exprs ...dbx.Expression) []outstandingLink {
+	exprs = append(exprs, dbx.HashExp{"user": userId, "status": "issued"})
+	records, err := app.FindAllRecords("invite_links", exprs...)
+	if err != nil {
+		return nil
+	}
+
+	var links []outstandingLink
+	for _, record := range records {
+		community, err := app.FindRecordById("communities", record.GetString("community"))
+		if err != nil {
+			continue
+		}
+		chatID, err := strconv.ParseInt(community.GetString("telegram_id"), 10, 64)
+		if err != nil {
+			continue
+		}
+		links = append(links, outstandingLink{
+			link:      record.GetString("link"),
+			chatID:    chatID,
+			community: community.GetString("name"),
+		})
+	}
+	return links
+}
+
+// RemoveMember revokes a member's invite link to one community and kicks them from
+// its chat, after an admin removed them from the community
+func (b *Bot) RemoveMember(user, community *core.Record) {
+	b.revokeLinks(user, findOutstandingLinks(b.app, user.Id, dbx.HashExp{"community": community.Id}))
+
+	if telegramID, err := strconv.ParseInt(user.GetString("telegram_id"), 10, 64); err == nil {
+		b.kickFromChat(user, telegramID, community)
+	}
+}
+
+// removeFromChats revokes the user's outstanding invite links and kicks them from
+// every managed community chat
+func (b *Bot) removeFromChats(user *core.Record, links []outstandingLink) {
+	b.revokeLinks(user, links)
+
+	telegramID, err := strconv.ParseInt(user.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	communities, err := b.app.FindAllRecords("communities", dbx.Not(dbx.HashExp{"telegram_id": ""}))
+	if err != nil {
+		log.Printf("⚠️  Failed to load communities: %v", err)
+		return
+	}
+
+	for _, community := range communities {
+		b.kickFromChat(user, telegramID, community)
+	}
+}
+
+// revokeLinks revokes invite links on Telegram and marks them revoked
+func (b *Bot) revokeLinks(user *core.Record, links []outstandingLink) {
+	email := user.GetString("email")
+
+	for _, l := range links {
+		if _, err := b.client.Request(tgbotapi.RevokeChatInviteLinkConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: l.chatID}, InviteLink: l.link}); err != nil {
+			log.Printf("⚠️  INVITE REVOKE FAILED - Community: %s | Email: %s | Error: %v", l.community, email, err)
+			continue
+		}
+		log.Printf("🔒 INVITE REVOKED - Community: %s | Email: %s", l.community, email)
+
+		if record, err := b.app.FindFirstRecordByData("invite_links", "link", l.link); err == nil {
+			record.Set("status", "revoked")
+			if err := b.app.Save(record); err != nil {
+				log.Printf("⚠️  Failed to mark invite link revoked: %v", err)
+			}
+		}
+	}
+}
+
+// kickFromChat bans then unbans a user from a community chat, so they may be
+// invited again later. user is nil for Telegram accounts not linked to anyone.
+func (b *Bot) kickFromChat(user *core.Record, telegramID int64, community *core.Record) error {
+	chatID, err := strconv.ParseInt(community.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s has no linked chat", community.GetString("name"))
+	}
+
+	email := "unlinked"
+	if user != nil {
+		email = user.GetString("email")
+	}
+	member := tgbotapi.ChatMemberConfig{ChatID: chatID, UserID: telegramID}
+	if _, err := b.client.Request(tgbotapi.BanChatMemberConfig{ChatMemberConfig: member}); err != nil {
+		log.Printf("⚠️  KICK FAILED - Community: %s | Email: %s | TG_ID: %d | Error: %v", community.GetString("name"), email, telegramID, err)
+		return fmt.Errorf("failed to remove them from the chat: %w", err)
+	}
+	if _, err := b.client.Request(tgbotapi.UnbanChatMemberConfig{ChatMemberConfig: member, OnlyIfBanned: true}); err != nil {
+		log.Printf("⚠️  UNBAN FAILED - Community: %s | Email: %s | TG_ID: %d | Error: %v", community.GetString("name"), email, telegramID, err)
+		return fmt.Errorf("removed them from the chat but failed to lift the ban, so they can't rejoin: %w", err)
+	}
+	log.Printf("👢 MEMBER REMOVED - Community: %s | Email: %s | TG_ID: %d", community.GetString("name"), email, telegramID)
+	return nil
+}
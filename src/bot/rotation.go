@@ -0,0 +1,415 @@
+package bot
+
+import (
+	"disciplo/src/config"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Policies for choosing the next group admin of a community
+const (
+	// RotationRoundRobin asks the members who were admin least recently first,
+	// starting with those who never were
+	RotationRoundRobin = "round_robin"
+	// RotationLongestTenure asks the longest-standing members first
+	RotationLongestTenure = "longest_tenure"
+	// RotationVolunteers only asks members who volunteered, in round-robin order
+	RotationVolunteers = "volunteers"
+)
+
+// Callback data prefix and actions for the rotation proposal keyboard
+const (
+	callbackRotation = "rotate"
+	rotationAccept   = "accept"
+	rotationDecline  = "decline"
+)
+
+// FindGroupAdmins returns the group admins of a community
+func FindGroupAdmins(app core.App, community *core.Record) ([]*core.Record, error) {
+	return app.FindAllRecords("users", dbx.HashExp{"group_admin": community.Id})
+}
+
+// RunRotation expires unanswered proposals and proposes a successor for every
+// group admin of an active community who has served longer than the configured tenure
+func (b *Bot) RunRotation(rotation config.RotationConfig) {
+	b.expireRotations(time.Duration(rotation.ResponseDays) * 24 * time.Hour)
+
+	communities, err := b.app.FindAllRecords("communities", dbx.HashExp{"archived": false})
+	if err != nil {
+		log.Printf("⚠️  Failed to load communities for rotation: %v", err)
+		return
+	}
+
+	tenure := time.Duration(rotation.TenureDays) * 24 * time.Hour
+	for _, community := range communities {
+		admins, err := FindGroupAdmins(b.app, community)
+		if err != nil {
+			log.Printf("⚠️  Failed to load the admins of %s: %v", community.GetString("name"), err)
+			continue
+		}
+
+		for _, admin := range admins {
+			since := admin.GetDateTime("group_admin_since")
+			if since.IsZero() || time.Since(since.Time()) < tenure {
+				continue
+			}
+			if _, err := b.openRotation(community, admin); err == nil {
+				continue
+			}
+
+			if err := b.proposeRotation(community, admin, rotation.Policy); err != nil {
+				log.Printf("⚠️  Failed to propose a new admin for %s: %v", community.GetString("name"), err)
+			}
+		}
+	}
+}
+
+// openRotation returns the unanswered proposal to succeed an admin of a community
+func (b *Bot) openRotation(community, outgoing *core.Record) (*core.Record, error) {
+	return b.app.FindFirstRecordByFilter("admin_rotations",
+		"community = {:community} && outgoing = {:outgoing} && status = 'proposed'",
+		dbx.Params{"community": community.Id, "outgoing": outgoing.Id})
+}
+
+// expireRotations closes the proposals left unanswered for longer than ttl, so the
+// next candidate is asked on this run
+func (b *Bot) expireRotations(ttl time.Duration) {
+	stale, err := b.app.FindAllRecords("admin_rotations",
+		dbx.HashExp{"status": "proposed"},
+		dbx.NewExp("created < {:cutoff}", dbx.Params{"cutoff": types.NowDateTime().Add(-ttl)}),
+	)
+	if err != nil {
+		log.Printf("⚠️  Failed to load rotation proposals: %v", err)
+		return
+	}
+
+	for _, rotation := range stale {
+		rotation.Set("status", "expired")
+		rotation.Set("decided_at", types.NowDateTime())
+		if err := b.app.Save(rotation); err != nil {
+			log.Printf("⚠️  Failed to expire rotation %s: %v", rotation.Id, err)
+			continue
+		}
+		log.Printf("⌛ ROTATION EXPIRED - Rotation: %s", rotation.Id)
+	}
+}
+
+// proposeRotation picks the community's next group admin with the given policy and
+// asks them by DM, letting the outgoing admin know
+func (b *Bot) proposeRotation(community, outgoing *core.Record, policy string) error {
+	candidates, err := b.rotationCandidates(community, outgoing)
+	if err != nil {
+		return err
+	}
+	candidates = b.orderCandidates(community, candidates, policy)
+	if len(candidates) == 0 {
+		log.Printf("🔄 ROTATION STALLED - Community: %s | Policy: %s | No eligible member", community.GetString("name"), policy)
+		b.NotifyAdmins(fmt.Sprintf("🔄 No eligible member is left to take over as admin of %q (policy: %s). The current admin stays on.",
+			community.GetString("name"), policy))
+		return nil
+	}
+	next := candidates[0]
+
+	collection, err := b.app.FindCollectionByNameOrId("admin_rotations")
+	if err != nil {
+		return err
+	}
+	rotation := core.NewRecord(collection)
+	rotation.Set("community", community.Id)
+	rotation.Set("proposed", next.Id)
+	rotation.Set("policy", policy)
+	rotation.Set("status", "proposed")
+	if outgoing != nil {
+		rotation.Set("outgoing", outgoing.Id)
+	}
+	if err := b.app.Save(rotation); err != nil {
+		return fmt.Errorf("failed to save rotation: %w", err)
+	}
+
+	text := fmt.Sprintf("🔄 It's time for a new group admin in \"%s\".\n\nWould you like to take over?", community.GetString("name"))
+	if outgoing != nil {
+		text = fmt.Sprintf("🔄 %s has been the group admin of \"%s\" since %s and it's time to hand over.\n\nWould you like to be the next admin?",
+			outgoing.GetString("name"), community.GetString("name"), formatStatusDate(outgoing.GetDateTime("group_admin_since")))
+	}
+	msg := tgbotapi.NewMessage(telegramChatID(next), text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Accept", fmt.Sprintf("%s:%s:%s", callbackRotation, rotationAccept, rotation.Id)),
+		tgbotapi.NewInlineKeyboardButtonData("❌ Decline", fmt.Sprintf("%s:%s:%s", callbackRotation, rotationDecline, rotation.Id)),
+	))
+	if _, err := b.client.Send(msg); err != nil {
+		log.Printf("⚠️  Failed to send rotation proposal to %s: %v", next.GetString("email"), err)
+	}
+
+	if outgoing != nil && outgoing.GetString("telegram_id") != "" {
+		b.client.Send(tgbotapi.NewMessage(telegramChatID(outgoing), fmt.Sprintf(
+			"🔄 Your tenure as group admin of \"%s\" is coming to an end. We asked %s to take over; you stay admin until they accept.",
+			community.GetString("name"), next.GetString("name"))))
+	}
+
+	log.Printf("🔄 ROTATION PROPOSED - Community: %s | Next: %s | Policy: %s", community.GetString("name"), next.GetString("email"), policy)
+	return nil
+}
+
+// rotationCandidates returns the verified members of a community on Telegram who
+// could become its admin: not already an admin of any community, not asked to
+// succeed the outgoing admin before and not asked by another open proposal
+func (b *Bot) rotationCandidates(community, outgoing *core.Record) ([]*core.Record, error) {
+	exprs := []dbx.Expression{
+		dbx.HashExp{"status": "accepted", "verified": true, "group_admin": ""},
+		dbx.Not(dbx.HashExp{"telegram_id": ""}),
+		memberOfExpr(community.Id),
+	}
+
+	var outgoingId string
+	var roundStart types.DateTime
+	if outgoing != nil {
+		outgoingId = outgoing.Id
+		roundStart = outgoing.GetDateTime("group_admin_since")
+	}
+	previous, err := b.app.FindAllRecords("admin_rotations", dbx.Or(
+		dbx.And(
+			dbx.HashExp{"community": community.Id, "outgoing": outgoingId, "status": []interface{}{"declined", "expired"}},
+			dbx.NewExp("created >= {:since}", dbx.Params{"since": roundStart}),
+		),
+		dbx.HashExp{"status": "proposed"},
+	))
+	if err != nil {
+		return nil, err
+	}
+	excluded := make([]interface{}, 0, len(previous)+1)
+	for _, rotation := range previous {
+		excluded = append(excluded, rotation.GetString("proposed"))
+	}
+	if outgoing != nil {
+		excluded = append(excluded, outgoing.Id)
+	}
+	if len(excluded) > 0 {
+		exprs = append(exprs, dbx.NotIn("id", excluded...))
+	}
+
+	return b.app.FindAllRecords("users", exprs...)
+}
+
+// orderCandidates sorts the candidates by the rotation policy, dropping the ones it
+// excludes. Unknown policies fall back to round-robin.
+func (b *Bot) orderCandidates(community *core.Record, candidates []*core.Record, policy string) []*core.Record {
+	var key func(user *core.Record) time.Time
+	switch policy {
+	case RotationLongestTenure:
+		key = func(user *core.Record) time.Time { return b.memberSince(community, user) }
+	case RotationVolunteers:
+		volunteers := candidates[:0:0]
+		for _, user := range candidates {
+			if user.GetBool("admin_volunteer") {
+				volunteers = append(volunteers, user)
+			}
+		}
+		candidates = volunteers
+		fallthrough
+	default:
+		key = func(user *core.Record) time.Time { return b.lastTenure(community, user) }
+	}
+
+	keys := make(map[string]time.Time, len(candidates))
+	for _, user := range candidates {
+		keys[user.Id] = key(user)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ki, kj := keys[candidates[i].Id], keys[candidates[j].Id]
+		if ki.Equal(kj) {
+			return candidates[i].GetDateTime("created").Time().Before(candidates[j].GetDateTime("created").Time())
+		}
+		return ki.Before(kj)
+	})
+	return candidates
+}
+
+// lastTenure returns when the user last became admin of the community, zero if they
+// never were
+func (b *Bot) lastTenure(community, user *core.Record) time.Time {
+	rotations := []*core.Record{}
+	err := b.app.RecordQuery("admin_rotations").
+		AndWhere(dbx.HashExp{"community": community.Id, "proposed": user.Id, "status": "confirmed"}).
+		OrderBy("decided_at DESC").Limit(1).All(&rotations)
+	if err != nil || len(rotations) == 0 {
+		return time.Time{}
+	}
+	return rotations[0].GetDateTime("decided_at").Time()
+}
+
+// memberSince returns when the user joined the community chat, or their account was
+// created when the join wasn't recorded
+func (b *Bot) memberSince(community, user *core.Record) time.Time {
+	links := []*core.Record{}
+	err := b.app.RecordQuery("invite_links").
+		AndWhere(dbx.HashExp{"community": community.Id, "user": user.Id, "status": "consumed"}).
+		OrderBy("consumed_at ASC").Limit(1).All(&links)
+	if err == nil && len(links) > 0 {
+		return links[0].GetDateTime("consumed_at").Time()
+	}
+	return user.GetDateTime("created").Time()
+}
+
+// handleRotationCallback answers the proposed admin's Accept and Decline buttons
+func (b *Bot) handleRotationCallback(query *tgbotapi.CallbackQuery) {
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 || query.Message == nil {
+		return
+	}
+
+	rotation, err := b.app.FindRecordById("admin_rotations", parts[2])
+	if err != nil {
+		b.client.Request(tgbotapi.NewCallback(query.ID, "This proposal no longer exists."))
+		return
+	}
+	user, err := FindUserByTelegramID(b.app, query.From.ID)
+	if err != nil || user.Id != rotation.GetString("proposed") {
+		b.client.Request(tgbotapi.NewCallback(query.ID, "This proposal isn't addressed to you."))
+		return
+	}
+
+	var result string
+	switch {
+	case rotation.GetString("status") != "proposed":
+		result = "ℹ️ This proposal is no longer open."
+	case parts[1] == rotationAccept:
+		if err := b.ConfirmRotation(rotation); err != nil {
+			result = fmt.Sprintf("❌ %v", err)
+		} else {
+			result = "✅ You are now the group admin. Thank you!"
+		}
+	case parts[1] == rotationDecline:
+		result = "👍 No problem, we'll ask someone else."
+		b.declineRotation(rotation)
+	default:
+		return
+	}
+
+	b.client.Request(tgbotapi.NewCallback(query.ID, ""))
+	b.client.Send(tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, result))
+}
+
+// declineRotation records a declined proposal and asks the next candidate
+func (b *Bot) declineRotation(rotation *core.Record) {
+	rotation.Set("status", "declined")
+	rotation.Set("decided_at", types.NowDateTime())
+	if err := b.app.Save(rotation); err != nil {
+		log.Printf("⚠️  Failed to decline rotation %s: %v", rotation.Id, err)
+		return
+	}
+	log.Printf("🙅 ROTATION DECLINED - Rotation: %s", rotation.Id)
+
+	community, err := b.app.FindRecordById("communities", rotation.GetString("community"))
+	if err != nil {
+		return
+	}
+	outgoing, _ := b.app.FindRecordById("users", rotation.GetString("outgoing"))
+	if err := b.proposeRotation(community, outgoing, rotation.GetString("policy")); err != nil {
+		log.Printf("⚠️  Failed to propose a new admin for %s: %v", community.GetString("name"), err)
+	}
+}
+
+// ConfirmRotation hands a community's group admin role to the proposed member,
+// then promotes them and demotes the outgoing admin in the community chat
+func (b *Bot) ConfirmRotation(rotation *core.Record) error {
+	var community, proposed, outgoing *core.Record
+	err := b.app.RunInTransaction(func(txApp core.App) error {
+		var err error
+		if community, err = txApp.FindRecordById("communities", rotation.GetString("community")); err != nil {
+			return fmt.Errorf("the community no longer exists")
+		}
+		if proposed, err = txApp.FindRecordById("users", rotation.GetString("proposed")); err != nil {
+			return fmt.Errorf("your account no longer exists")
+		}
+		if proposed.GetString("status") != "accepted" || !proposed.GetBool("verified") {
+			return fmt.Errorf("only active members can become group admin")
+		}
+		if admin := proposed.GetString("group_admin"); admin != "" && admin != community.Id {
+			return fmt.Errorf("you are already the group admin of another community")
+		}
+
+		if current, err := txApp.FindRecordById("users", rotation.GetString("outgoing")); err == nil &&
+			current.GetString("group_admin") == community.Id && current.Id != proposed.Id {
+			current.Set("group_admin", "")
+			current.Set("group_admin_since", "")
+			if err := txApp.Save(current); err != nil {
+				return fmt.Errorf("failed to update the outgoing admin")
+			}
+			outgoing = current
+		}
+
+		proposed.Set("group_admin", community.Id)
+		proposed.Set("group_admin_since", types.NowDateTime())
+		if err := txApp.Save(proposed); err != nil {
+			return fmt.Errorf("failed to update your account")
+		}
+
+		rotation.Set("status", "confirmed")
+		rotation.Set("decided_at", types.NowDateTime())
+		return txApp.Save(rotation)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("👑 ROTATION CONFIRMED - Community: %s | Admin: %s", community.GetString("name"), proposed.GetString("email"))
+
+	if community.GetString("telegram_id") != "" {
+		if err := b.SetChatAdmin(community, proposed, true); err != nil {
+			log.Printf("⚠️  Failed to promote %s in %s: %v", proposed.GetString("email"), community.GetString("name"), err)
+		}
+		if outgoing != nil {
+			if err := b.SetChatAdmin(community, outgoing, false); err != nil {
+				log.Printf("⚠️  Failed to demote %s in %s: %v", outgoing.GetString("email"), community.GetString("name"), err)
+			}
+		}
+	}
+
+	if outgoing != nil && outgoing.GetString("telegram_id") != "" {
+		b.client.Send(tgbotapi.NewMessage(telegramChatID(outgoing), fmt.Sprintf(
+			"👑 %s is now the group admin of \"%s\". Thank you for your time as admin!",
+			proposed.GetString("name"), community.GetString("name"))))
+	}
+	b.NotifyAdmins(fmt.Sprintf("👑 %s is the new group admin of \"%s\".", proposed.GetString("name"), community.GetString("name")))
+
+	return nil
+}
+
+// SetChatAdmin promotes a member to administrator of the community chat, with the
+// rights needed to moderate it, or demotes them back to a regular member
+func (b *Bot) SetChatAdmin(community, user *core.Record, admin bool) error {
+	chatID, err := strconv.ParseInt(community.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("community %s has no linked chat", community.GetString("name"))
+	}
+	telegramID, err := strconv.ParseInt(user.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("user %s has no valid telegram_id", user.GetString("email"))
+	}
+
+	_, err = b.client.Request(tgbotapi.PromoteChatMemberConfig{
+		ChatMemberConfig:    tgbotapi.ChatMemberConfig{ChatID: chatID, UserID: telegramID},
+		CanManageChat:       admin,
+		CanDeleteMessages:   admin,
+		CanManageVoiceChats: admin,
+		CanInviteUsers:      admin,
+		CanRestrictMembers:  admin,
+		CanPinMessages:      admin,
+	})
+	return err
+}
+
+// telegramChatID returns the private chat ID of a user with a linked Telegram account
+func telegramChatID(user *core.Record) int64 {
+	chatID, _ := strconv.ParseInt(user.GetString("telegram_id"), 10, 64)
+	return chatID
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"disciplo/src/config"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// allowedUpdates are the update types the bot subscribes to; chat_member updates
+// are only delivered when explicitly requested
+var allowedUpdates = []string{"message", "chat_member", "my_chat_member", "chat_join_request", "callback_query"}
+
+// running is the bot connected to Telegram, once run has started it
+var running atomic.Pointer[Bot]
+
+// Running returns the bot connected to Telegram, or nil while it is starting or
+// when it failed to start
+func Running() *Bot {
+	return running.Load()
+}
+
+const (
+	webhookQueueSize   = 100
+	maxWebhookBodySize = 1 << 20
+)
+
+// Setup checks the bot's message templates and starts the Telegram bot in the
+// background. In webhook mode Telegram delivers updates through a route on the
+// PocketBase router.
+func Setup(app core.App, cfg *config.Config) error {
+	templateDir := DefaultTemplateDir
+	if disciploConfig, err := config.LoadDisciploConfig(); err == nil && disciploConfig.Telegram.TemplatePath != "" {
+		templateDir = disciploConfig.Telegram.TemplatePath
+	}
+	if err := LoadTemplates(templateDir); err != nil {
+		return err
+	}
+
+	var webhookUpdates chan tgbotapi.Update
+	if cfg.BotMode == "webhook" {
+		webhookUpdates = make(chan tgbotapi.Update, webhookQueueSize)
+		app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+			e.Router.POST("/telegram/webhook/{secret}", func(re *core.RequestEvent) error {
+				return handleWebhook(re, cfg, webhookUpdates)
+			})
+			return e.Next()
+		})
+	}
+
+	// Kick members from community chats when they lose access
+	registerRemovalHooks(app)
+
+	go run(app, cfg, webhookUpdates)
+	return nil
+}
+
+// run connects to Telegram, schedules the bot's jobs and feeds it updates
+func run(app core.App, cfg *config.Config, webhookUpdates <-chan tgbotapi.Update) {
+	api, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	if err != nil {
+		log.Printf("❌ Bot failed to start: %v", err)
+		log.Printf("💡 Check BOT_TOKEN in .env file")
+		return
+	}
+
+	if cfg.DevMode {
+		api.Debug = true
+	}
+
+	log.Printf("✅ Telegram bot ready: @%s", api.Self.UserName)
+
+	// Update .env reminder if needed
+	if cfg.BotUsername == "" || cfg.BotUsername == "your_bot_username" {
+		log.Printf("💡 Update BOT_USERNAME in .env to: %s", api.Self.UserName)
+	}
+
+	b := New(api, api.Self, app, cfg)
+	running.Store(b)
+
+	if disciploConfig, err := config.LoadDisciploConfig(); err == nil {
+		// Periodically compare chat membership with the database
+		if schedule := disciploConfig.Telegram.ReconcileSchedule; schedule != "" {
+			if err := app.Cron().Add("telegramReconcile", schedule, b.runReconciliation); err != nil {
+				log.Printf("⚠️  Invalid telegram.reconcile_schedule: %v", err)
+			}
+		}
+
+		// Propose new group admins once their tenure is over
+		rotation := disciploConfig.Communities.Rotation
+		if rotation.Schedule != "" {
+			if err := app.Cron().Add("adminRotation", rotation.Schedule, func() { b.RunRotation(rotation) }); err != nil {
+				log.Printf("⚠️  Invalid communities.rotation.schedule: %v", err)
+			}
+		}
+	}
+
+	var updates <-chan tgbotapi.Update
+	if webhookUpdates != nil {
+		if err := setWebhook(api, cfg); err != nil {
+			log.Printf("❌ Failed to register Telegram webhook: %v", err)
+			return
+		}
+		log.Printf("🪝 Telegram webhook registered at %s/telegram/webhook/…", cfg.Host)
+
+		app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+			if _, err := api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+				log.Printf("⚠️  Failed to delete Telegram webhook: %v", err)
+			}
+			return e.Next()
+		})
+		updates = webhookUpdates
+	} else {
+		// getUpdates is refused while a webhook is set, e.g. after switching modes
+		if _, err := api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			log.Printf("⚠️  Failed to delete Telegram webhook: %v", err)
+		}
+
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 60
+		u.AllowedUpdates = allowedUpdates
+		updates = api.GetUpdatesChan(u)
+	}
+
+	for update := range updates {
+		b.HandleUpdate(update)
+	}
+}
+
+// setWebhook points Telegram at our webhook route. The library's WebhookConfig
+// predates secret_token, so the request is built by hand.
+func setWebhook(api *tgbotapi.BotAPI, cfg *config.Config) error {
+	if !strings.HasPrefix(cfg.Host, "https://") {
+		return fmt.Errorf("webhook mode needs an https HOST, got %s", cfg.Host)
+	}
+
+	allowed, err := json.Marshal(allowedUpdates)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.MakeRequest("setWebhook", tgbotapi.Params{
+		"url":             cfg.Host + "/telegram/webhook/" + cfg.BotWebhookSecret,
+		"secret_token":    cfg.BotWebhookSecret,
+		"allowed_updates": string(allowed),
+	})
+	return err
+}
+
+// handleWebhook validates a webhook call from Telegram and queues its update
+func handleWebhook(e *core.RequestEvent, cfg *config.Config, updates chan<- tgbotapi.Update) error {
+	secret := []byte(cfg.BotWebhookSecret)
+	if subtle.ConstantTimeCompare([]byte(e.Request.PathValue("secret")), secret) != 1 ||
+		subtle.ConstantTimeCompare([]byte(e.Request.Header.Get("X-Telegram-Bot-Api-Secret-Token")), secret) != 1 {
+		return e.NoContent(http.StatusNotFound)
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(e.Response, e.Request.Body, maxWebhookBodySize)).Decode(&update); err != nil {
+		return e.NoContent(http.StatusBadRequest)
+	}
+
+	select {
+	case updates <- update:
+		return e.NoContent(http.StatusOK)
+	default:
+		// Queue full, Telegram retries the update later
+		log.Printf("⚠️  Telegram webhook queue full, deferring update %d", update.UpdateID)
+		return e.NoContent(http.StatusServiceUnavailable)
+	}
+}
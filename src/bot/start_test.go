@@ -0,0 +1,141 @@
+package bot_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"disciplo/src/bot"
+	"disciplo/src/bot/bottest"
+	"disciplo/src/config"
+
+	_ "disciplo/src/migrations"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+const communityChatID = -1001
+
+var applicant = tgbotapi.User{ID: 4242, FirstName: "Ada", UserName: "ada"}
+
+// newStartFixture returns a test app with an accepted, not yet linked member holding
+// the given Telegram token, and a default community whose chat the fake bot manages
+func newStartFixture(t *testing.T, token string, tokenCreated time.Time) (*tests.TestApp, *bottest.Client, *bot.Bot) {
+	t.Helper()
+
+	if err := bot.LoadTemplates(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	communities, err := app.FindCollectionByNameOrId("communities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	community := core.NewRecord(communities)
+	community.Set("name", "General")
+	community.Set("type", "default")
+	community.Set("telegram_id", strconv.Itoa(communityChatID))
+	if err := app.SaveNoValidate(community); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.Set("name", "Ada Lovelace")
+	user.Set("email", "ada@example.com")
+	user.Set("status", "accepted")
+	user.Set("role", "member")
+	user.Set("telegram_token", token)
+	user.Set("telegram_token_created", tokenCreated)
+	user.SetPassword("registered-secret")
+	if err := app.SaveNoValidate(user); err != nil {
+		t.Fatal(err)
+	}
+
+	client := bottest.NewClient()
+	client.AddChat(tgbotapi.Chat{ID: communityChatID, Type: "supergroup", Title: "General"})
+
+	return app, client, bot.New(client, bottest.Self, app, &config.Config{Host: "http://localhost:8090"})
+}
+
+func TestStartLinksTelegramAccount(t *testing.T) {
+	app, client, b := newStartFixture(t, "valid-token", time.Now())
+
+	b.HandleUpdate(bottest.CommandUpdate(applicant, "/start valid-token"))
+
+	user, err := app.FindAuthRecordByEmail("users", "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := user.GetString("telegram_id"); got != "4242" {
+		t.Errorf("telegram_id = %q, want %q", got, "4242")
+	}
+	if got := user.GetString("telegram_name"); got != "ada" {
+		t.Errorf("telegram_name = %q, want %q", got, "ada")
+	}
+	if !user.GetBool("verified") {
+		t.Error("user is not verified")
+	}
+	if got := user.GetString("telegram_token"); got != "" {
+		t.Errorf("telegram_token = %q, want it cleared", got)
+	}
+
+	messages := client.MessagesTo(applicant.ID)
+	if len(messages) != 2 {
+		t.Fatalf("sent %d messages, want the confirmation and the invite links: %q", len(messages), messages)
+	}
+	if !strings.Contains(messages[0], "Telegram Connected Successfully") {
+		t.Errorf("first message = %q, want the connection confirmation", messages[0])
+	}
+	if !strings.Contains(messages[1], "Your Community Groups") {
+		t.Errorf("second message = %q, want the invite links", messages[1])
+	}
+}
+
+func TestStartRejectsBadTokens(t *testing.T) {
+	scenarios := []struct {
+		name         string
+		tokenCreated time.Time
+		command      string
+		reply        string
+	}{
+		{"unknown token", time.Now(), "/start other-token", "Invalid or Expired Token"},
+		{"expired token", time.Now().Add(-25 * time.Hour), "/start valid-token", "Token Expired"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			app, client, b := newStartFixture(t, "valid-token", s.tokenCreated)
+
+			b.HandleUpdate(bottest.CommandUpdate(applicant, s.command))
+
+			user, err := app.FindAuthRecordByEmail("users", "ada@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.GetString("telegram_id") != "" || user.GetBool("verified") {
+				t.Errorf("user was linked: telegram_id = %q, verified = %v", user.GetString("telegram_id"), user.GetBool("verified"))
+			}
+
+			messages := client.MessagesTo(applicant.ID)
+			if len(messages) != 1 || !strings.Contains(messages[0], s.reply) {
+				t.Errorf("sent %q, want a single %q reply", messages, s.reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"disciplo/src/permissions"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// statusData is the data contract of the status.md template
+type statusData struct {
+	// Telegram account
+	TelegramID int64
+	Username   string
+	FirstName  string
+	LastName   string
+
+	// Linked users record, only set when Linked is true
+	Linked   bool
+	Name     string
+	Email    string
+	Status   string
+	Verified bool
+	IsAdmin  bool     // Superadmin, kept for templates written before roles
+	Role     string   // One of the permissions roles
+	Scope    []string // Names of the communities a scoped role is limited to
+
+	// Request history
+	SubmittedAt string
+	ApprovedAt  string
+
+	Communities []statusCommunity
+	// Outstanding invite links the user still has to use
+	Invites        []statusCommunity
+	PendingActions []string
+}
+
+// statusCommunity is a community the user belongs to, with a link to its chat when
+// one is available
+type statusCommunity struct {
+	Name string
+	Link string
+}
+
+// handleStatus reports the caller's membership as recorded in the users collection
+func (b *Bot) handleStatus(message *tgbotapi.Message) {
+	data := b.statusFor(message.From)
+
+	fallback := fmt.Sprintf("Your Account\n\nTelegram ID: %d\nLinked: %v", data.TelegramID, data.Linked)
+	if data.Linked {
+		fallback += fmt.Sprintf("\nStatus: %s\nVerified: %v\nCommunities: %d", data.Status, data.Verified, len(data.Communities))
+	}
+
+	msg := newTemplateMessage(message.Chat.ID, "status.md", data, fallback)
+	msg.DisableWebPagePreview = true
+	b.client.Send(msg)
+}
+
+// statusFor gathers the status of the Disciplo account linked to a Telegram user
+func (b *Bot) statusFor(from *tgbotapi.User) *statusData {
+	data := &statusData{
+		TelegramID: from.ID,
+		Username:   from.UserName,
+		FirstName:  from.FirstName,
+		LastName:   from.LastName,
+	}
+
+	user, err := FindUserByTelegramID(b.app, from.ID)
+	if err != nil {
+		return data
+	}
+
+	data.Linked = true
+	data.Name = user.GetString("name")
+	data.Email = user.GetString("email")
+	data.Status = user.GetString("status")
+	data.Verified = user.GetBool("verified")
+	data.IsAdmin = permissions.IsSuperadmin(user)
+	data.Role = string(permissions.RoleOf(user))
+	if access, err := permissions.Resolve(b.app, user); err == nil && !access.Global && len(access.Communities) > 0 {
+		scoped, _ := b.app.FindRecordsByIds("communities", access.Communities)
+		for _, community := range scoped {
+			data.Scope = append(data.Scope, community.GetString("name"))
+		}
+	}
+
+	if request, err := b.app.FindFirstRecordByFilter("requests", "created_user_id = {:id}", dbx.Params{"id": user.Id}); err == nil {
+		data.SubmittedAt = formatStatusDate(request.GetDateTime("created"))
+		data.ApprovedAt = formatStatusDate(request.GetDateTime("approved_at"))
+	}
+
+	// Outstanding invite links double as the chat link for private chats
+	invites := make(map[string]string)
+	issued, _ := b.app.FindAllRecords("invite_links", dbx.HashExp{"user": user.Id, "status": "issued"},
+		dbx.NewExp("expires_at > {:now}", dbx.Params{"now": types.NowDateTime()}))
+	for _, invite := range issued {
+		invites[invite.GetString("community")] = invite.GetString("link")
+	}
+
+	if groups := user.GetStringSlice("groups"); len(groups) > 0 {
+		communities, _ := b.app.FindRecordsByIds("communities", groups)
+		for _, community := range communities {
+			data.Communities = append(data.Communities, statusCommunity{
+				Name: community.GetString("name"),
+				Link: communityLink(community, invites[community.Id]),
+			})
+			if link := invites[community.Id]; link != "" {
+				data.Invites = append(data.Invites, statusCommunity{Name: community.GetString("name"), Link: link})
+			}
+		}
+	}
+
+	if data.Status != "accepted" {
+		data.PendingActions = append(data.PendingActions, "Wait for an admin to approve your membership")
+	}
+	if !data.Verified {
+		data.PendingActions = append(data.PendingActions, "Contact an admin to verify your account")
+	}
+
+	return data
+}
+
+// communityLink returns the public link of a community chat, falling back to the
+// user's own invite link
+func communityLink(community *core.Record, invite string) string {
+	if username := community.GetString("telegram_username"); username != "" {
+		return "https://t.me/" + username
+	}
+	return invite
+}
+
+func formatStatusDate(date types.DateTime) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Time().Format("2006-01-02")
+}
@@ -0,0 +1,282 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// maxReportedDiscrepancies caps how many discrepancies are listed in the admin report
+	maxReportedDiscrepancies = 30
+
+	// telegramCallDelay spaces out the calls of a reconciliation, keeping it well
+	// under Telegram's limit of about 30 requests per second
+	telegramCallDelay = 50 * time.Millisecond
+
+	// maxRateLimitRetries is how often a call is retried after a 429 answer
+	maxRateLimitRetries = 3
+)
+
+// handleChatMember keeps users.groups and the community member count in sync when
+// someone joins or leaves a managed community chat. Joining only adds the community
+// for accepted, verified users; anyone else joining is only logged.
+func (b *Bot) handleChatMember(update *tgbotapi.ChatMemberUpdated) {
+	community, err := FindCommunityByChat(b.app, update.Chat.ID)
+	if err != nil {
+		return
+	}
+
+	recordInviteUsage(b.app, update)
+
+	joined := isInChat(update.NewChatMember)
+	if joined != isInChat(update.OldChatMember) && update.NewChatMember.User != nil {
+		user, err := FindUserByTelegramID(b.app, update.NewChatMember.User.ID)
+		if err != nil {
+			user = nil
+		}
+		if reason := membershipRefusalReason(user, community); joined && reason != "" {
+			// Someone let in by the chat's own admins doesn't become a member
+			log.Printf("⚠️  GROUP JOINED WITHOUT MEMBERSHIP - Community: %s | TG_ID: %d | Reason: %s", community.GetString("name"), update.NewChatMember.User.ID, reason)
+		} else if user != nil {
+			groups := user.GetStringSlice("groups")
+			if joined {
+				groups = appendUnique(groups, community.Id)
+			} else {
+				groups = removeValue(groups, community.Id)
+			}
+			user.Set("groups", groups)
+			if err := b.app.Save(user); err != nil {
+				log.Printf("⚠️  Failed to sync groups for %s: %v", user.GetString("email"), err)
+			} else if joined {
+				log.Printf("➕ GROUP JOINED - Community: %s | Email: %s", community.GetString("name"), user.GetString("email"))
+			} else {
+				log.Printf("➖ GROUP LEFT - Community: %s | Email: %s | Status: %s", community.GetString("name"), user.GetString("email"), update.NewChatMember.Status)
+			}
+		}
+	}
+
+	b.refreshMemberCount(community)
+}
+
+// handleMyChatMember tracks the bot's own membership in managed community chats,
+// warns admins when it loses access to one and offers to register new chats
+func (b *Bot) handleMyChatMember(update *tgbotapi.ChatMemberUpdated) {
+	community, err := FindCommunityByChat(b.app, update.Chat.ID)
+	if err != nil {
+		b.offerChatRegistration(update)
+		return
+	}
+
+	if !isInChat(update.NewChatMember) {
+		log.Printf("⚠️  BOT REMOVED - Community: %s | Chat: %d | Status: %s", community.GetString("name"), update.Chat.ID, update.NewChatMember.Status)
+		b.NotifyAdmins(fmt.Sprintf("⚠️ The bot was removed from the chat of %q (status: %s). Membership sync and join requests are paused for it until the bot is added back as an administrator.",
+			community.GetString("name"), update.NewChatMember.Status))
+		return
+	}
+
+	b.refreshMemberCount(community)
+}
+
+// refreshMemberCount stores the current Telegram member count of a community chat
+func (b *Bot) refreshMemberCount(community *core.Record) {
+	if _, err := b.RefreshMemberCount(community); err != nil {
+		log.Printf("⚠️  Failed to refresh member count for %s: %v", community.GetString("name"), err)
+	}
+}
+
+// RefreshMemberCount fetches the live member count of a community chat from
+// Telegram and stores it on the community
+func (b *Bot) RefreshMemberCount(community *core.Record) (int, error) {
+	chatID, err := strconv.ParseInt(community.GetString("telegram_id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("community %s has no linked chat", community.GetString("name"))
+	}
+
+	count, err := b.client.GetChatMembersCount(tgbotapi.ChatMemberCountConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+	if err != nil {
+		return 0, err
+	}
+
+	if community.GetInt("member_count") != count {
+		community.Set("member_count", count)
+		if err := b.app.Save(community); err != nil {
+			return count, fmt.Errorf("failed to save member count: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
+// Reconcile compares the Telegram membership of every managed community chat with
+// users.groups, refreshes the member counts and returns the discrepancies found.
+// Telegram can't list the members of a chat, so only the community's members and
+// the chat's administrators are checked, one call at a time.
+func (b *Bot) Reconcile() ([]string, error) {
+	communities, err := b.app.FindAllRecords("communities", dbx.Not(dbx.HashExp{"telegram_id": ""}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load communities: %w", err)
+	}
+
+	var discrepancies []string
+	for _, community := range communities {
+		chatID, err := strconv.ParseInt(community.GetString("telegram_id"), 10, 64)
+		if err != nil {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: invalid telegram_id %q", community.GetString("name"), community.GetString("telegram_id")))
+			continue
+		}
+
+		b.refreshMemberCount(community)
+
+		users, err := b.reconcileCandidates(community, chatID)
+		if err != nil {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: could not load the members to check (%v)", community.GetString("name"), err))
+			continue
+		}
+
+		for _, user := range users {
+			telegramID, err := strconv.ParseInt(user.GetString("telegram_id"), 10, 64)
+			if err != nil {
+				continue
+			}
+
+			var member tgbotapi.ChatMember
+			err = retryRateLimited(func() (err error) {
+				member, err = b.client.GetChatMember(tgbotapi.GetChatMemberConfig{ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: telegramID}})
+				return err
+			})
+			time.Sleep(telegramCallDelay)
+			if err != nil {
+				discrepancies = append(discrepancies, fmt.Sprintf("%s: could not check %s (%v)", community.GetString("name"), user.GetString("email"), err))
+				continue
+			}
+
+			inChat := isInChat(member)
+			inGroups := slices.Contains(user.GetStringSlice("groups"), community.Id)
+
+			switch {
+			case inChat && !inGroups:
+				discrepancies = append(discrepancies, fmt.Sprintf("%s: %s is in the chat but not in the community", community.GetString("name"), user.GetString("email")))
+			case !inChat && inGroups:
+				discrepancies = append(discrepancies, fmt.Sprintf("%s: %s is in the community but not in the chat", community.GetString("name"), user.GetString("email")))
+			case inChat && (user.GetString("status") != "accepted" || !user.GetBool("verified")):
+				discrepancies = append(discrepancies, fmt.Sprintf("%s: %s is in the chat without an active membership", community.GetString("name"), user.GetString("email")))
+			}
+		}
+	}
+
+	return discrepancies, nil
+}
+
+// reconcileCandidates returns the linked users whose presence in a community chat is
+// checked: the members of the community and the chat's administrators
+func (b *Bot) reconcileCandidates(community *core.Record, chatID int64) ([]*core.Record, error) {
+	users, err := b.app.FindAllRecords("users", memberOfExpr(community.Id), dbx.Not(dbx.HashExp{"telegram_id": ""}))
+	if err != nil {
+		return nil, err
+	}
+
+	var admins []tgbotapi.ChatMember
+	err = retryRateLimited(func() (err error) {
+		admins, err = b.client.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+		return err
+	})
+	time.Sleep(telegramCallDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, admin := range admins {
+		if admin.User == nil || admin.User.IsBot {
+			continue
+		}
+		user, err := FindUserByTelegramID(b.app, admin.User.ID)
+		if err != nil || slices.ContainsFunc(users, func(u *core.Record) bool { return u.Id == user.Id }) {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// retryRateLimited runs a Telegram call, waiting as long as Telegram asks and trying
+// again when it answers 429 Too Many Requests
+func retryRateLimited(call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+
+		var tgErr *tgbotapi.Error
+		if err == nil || attempt == maxRateLimitRetries || !errors.As(err, &tgErr) || tgErr.Code != http.StatusTooManyRequests {
+			return err
+		}
+
+		wait := time.Duration(tgErr.RetryAfter) * time.Second
+		if wait <= 0 {
+			wait = time.Second << attempt
+		}
+		log.Printf("⏳ Rate limited by Telegram, retrying in %s", wait)
+		time.Sleep(wait)
+	}
+}
+
+// runReconciliation runs Reconcile and reports the outcome to the admins
+func (b *Bot) runReconciliation() {
+	discrepancies, err := b.Reconcile()
+	if err != nil {
+		log.Printf("❌ Membership reconciliation failed: %v", err)
+		return
+	}
+
+	log.Printf("🔄 MEMBERSHIP RECONCILED - Discrepancies: %d", len(discrepancies))
+	if len(discrepancies) == 0 {
+		return
+	}
+
+	// Keep the report well under Telegram's 4096 character message limit
+	listed := discrepancies
+	if len(listed) > maxReportedDiscrepancies {
+		listed = listed[:maxReportedDiscrepancies]
+	}
+	report := fmt.Sprintf("🔄 Membership check found %d discrepancies:\n\n• %s",
+		len(discrepancies), strings.Join(listed, "\n• "))
+	if len(discrepancies) > len(listed) {
+		report += fmt.Sprintf("\n\n…and %d more, see the server log.", len(discrepancies)-len(listed))
+	}
+	for _, discrepancy := range discrepancies {
+		log.Printf("   • %s", discrepancy)
+	}
+
+	b.NotifyAdmins(report)
+}
+
+// NotifyAdmins sends a plain-text message to every admin with a linked Telegram account
+func (b *Bot) NotifyAdmins(text string) {
+	admins, err := b.app.FindAllRecords("users",
+		dbx.HashExp{"admin": true},
+		dbx.Not(dbx.HashExp{"telegram_id": ""}),
+	)
+	if err != nil {
+		log.Printf("⚠️  Failed to load admins: %v", err)
+		return
+	}
+
+	for _, admin := range admins {
+		chatID, err := strconv.ParseInt(admin.GetString("telegram_id"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := b.client.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf("⚠️  Failed to notify admin %s: %v", admin.GetString("email"), err)
+		}
+	}
+}
@@ -2,88 +2,185 @@ package bot
 
 import (
 	"bytes"
+	"embed"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"text/template"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// LoadTemplate loads and parses a bot template file
-func LoadTemplate(name string, data interface{}) (string, error) {
-	// Try to load from pb_public first (runtime), then from source
-	paths := []string{
-		filepath.Join("pb_public", "bot_templates", name),
-		filepath.Join("src", "static", "bot_templates", name),
+// DefaultTemplateDir is where operators may drop bot templates that replace the
+// embedded defaults, one file per template with the same name
+const DefaultTemplateDir = "pb_public/bot_templates"
+
+//go:embed templates/*.md
+var defaultTemplates embed.FS
+
+// connectionSuccessData is the data contract of connection_success.md
+type connectionSuccessData struct {
+	FirstName string
+	IsAdmin   bool
+}
+
+// groupInvitesData is the data contract of group_invites.md
+type groupInvitesData struct {
+	Name  string
+	Count int
+	TTL   int
+}
+
+// dashboardData is the data contract of dashboard_link.md
+type dashboardData struct {
+	URL string
+}
+
+// templateContracts lists every bot template with sample data covering each of its
+// branches. Templates are executed against these at startup so that a missing
+// field fails fast instead of when a user first triggers the message.
+var templateContracts = map[string][]interface{}{
+	"connection_success.md":  {connectionSuccessData{FirstName: "Ada", IsAdmin: true}, connectionSuccessData{FirstName: "Ada"}},
+	"dashboard_link.md":      {dashboardData{URL: "http://localhost:8080/dashboard"}},
+	"group_invites.md":       {groupInvitesData{Name: "Ada", Count: 1, TTL: 7}, groupInvitesData{Count: 2, TTL: 7}},
+	"help.md":                {nil},
+	"start_expired_token.md": {nil},
+	"start_failed.md":        {nil},
+	"start_invalid_token.md": {nil},
+	"start_welcome.md":       {nil},
+	"status.md": {
+		&statusData{TelegramID: 1, Username: "ada"},
+		&statusData{
+			TelegramID: 1, Linked: true, Name: "Ada", Email: "ada@example.com", Status: "accepted", Verified: true,
+			Role: "community_admin", Scope: []string{"North", "South"},
+			SubmittedAt: "2024-01-01", ApprovedAt: "2024-01-02",
+			Communities:    []statusCommunity{{Name: "General", Link: "https://t.me/general"}, {Name: "Local"}},
+			Invites:        []statusCommunity{{Name: "General", Link: "https://t.me/+invite"}},
+			PendingActions: []string{"Contact an admin"},
+		},
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	"md":     EscapeMarkdown,
+	"mdURL":  EscapeMarkdownURL,
+	"mdCode": func(value interface{}) string { return "`" + EscapeMarkdownCode(fmt.Sprint(value)) + "`" },
+}
+
+var (
+	templatesMu sync.RWMutex
+	templates   map[string]*template.Template
+)
+
+// LoadTemplates parses every bot template, preferring files in overrideDir over the
+// embedded defaults, and checks each one against its data contract and that it
+// renders valid MarkdownV2. It replaces the templates in use only when all of them
+// are valid.
+func LoadTemplates(overrideDir string) error {
+	if overrideDir == "" {
+		overrideDir = DefaultTemplateDir
 	}
-	
-	var content []byte
-	var err error
-	
-	for _, path := range paths {
-		content, err = os.ReadFile(path)
-		if err == nil {
-			break
+
+	loaded := make(map[string]*template.Template, len(templateContracts))
+	for name, samples := range templateContracts {
+		source, err := os.ReadFile(filepath.Join(overrideDir, name))
+		if os.IsNotExist(err) {
+			source, err = defaultTemplates.ReadFile("templates/" + name)
 		}
+		if err != nil {
+			return fmt.Errorf("bot template %s: %w", name, err)
+		}
+
+		tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(source))
+		if err != nil {
+			return fmt.Errorf("bot template %s: %w", name, err)
+		}
+		// Messages are sent as MarkdownV2, which Telegram refuses outright when
+		// a reserved character is left unescaped
+		for _, sample := range samples {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, sample); err != nil {
+				return fmt.Errorf("bot template %s: %w", name, err)
+			}
+			if err := validateMarkdownV2(buf.String()); err != nil {
+				return fmt.Errorf("bot template %s: %w", name, err)
+			}
+		}
+		loaded[name] = tmpl
 	}
-	
-	if err != nil {
-		return "", fmt.Errorf("template %s not found", name)
+
+	templatesMu.Lock()
+	templates = loaded
+	templatesMu.Unlock()
+	return nil
+}
+
+// renderTemplate executes a bot template. The embedded defaults are used when
+// LoadTemplates has not been called, e.g. in tests.
+func renderTemplate(name string, data interface{}) (string, error) {
+	templatesMu.RLock()
+	loaded := templates
+	templatesMu.RUnlock()
+
+	if loaded == nil {
+		if err := LoadTemplates(""); err != nil {
+			return "", err
+		}
+		return renderTemplate(name, data)
 	}
-	
-	tmpl, err := template.New(name).Parse(string(content))
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+
+	tmpl, ok := loaded[name]
+	if !ok {
+		return "", fmt.Errorf("bot template %s not found", name)
 	}
-	
+
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+		return "", fmt.Errorf("failed to execute bot template %s: %w", name, err)
 	}
-	
-	return buf.String(), nil
+	return strings.TrimSpace(buf.String()), nil
 }
 
-// GetDefaultTemplate returns a hardcoded fallback template
-func GetDefaultTemplate(name string) string {
-	templates := map[string]string{
-		"start_admin.md": `✅ **Admin Account Connected**
-
-Token: {{.Token}}
-
-**Your Telegram Details:**
-• ID: {{.TelegramID}}
-• Username: @{{.Username}}
-• Name: {{.FirstName}} {{.LastName}}
-
-Your admin account is now linked to Telegram!`,
-		"start_welcome.md": `Welcome to **Disciplo**! 🎉
+// newTemplateMessage renders a bot template into a MarkdownV2 message. When the
+// template fails, the message falls back to the given plain text.
+func newTemplateMessage(chatID int64, name string, data interface{}, fallback string) tgbotapi.MessageConfig {
+	text, err := renderTemplate(name, data)
+	if err != nil {
+		return tgbotapi.NewMessage(chatID, fallback)
+	}
 
-To connect your Telegram account, you need an invitation token from the admin.
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	return msg
+}
 
-**How to get access:**
-1. Contact your administrator
-2. Get an invitation link
-3. Click the link to return here with a token`,
-		"help.md": `**Disciplo Bot Commands**
+var (
+	markdownEscaper     = strings.NewReplacer(markdownEscapes("\\_*[]()~`>#+-=|{}.!")...)
+	markdownURLEscaper  = strings.NewReplacer(markdownEscapes("\\)")...)
+	markdownCodeEscaper = strings.NewReplacer(markdownEscapes("\\`")...)
+)
 
-🔗 **/start** - Connect account (requires invitation token)
-❓ **/help** - Show this help message  
-📊 **/status** - Check your account status
+// EscapeMarkdown escapes text for use in a MarkdownV2 message
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
 
-**Getting Started:**
-Contact your community admin for an invitation link.`,
-		"status.md": `📊 **Your Account**
+// EscapeMarkdownURL escapes a URL for use inside a MarkdownV2 inline link
+func EscapeMarkdownURL(url string) string {
+	return markdownURLEscaper.Replace(url)
+}
 
-• **Telegram ID:** {{.TelegramID}}
-• **Username:** @{{.Username}}
-• **Name:** {{.FirstName}} {{.LastName}}
+// EscapeMarkdownCode escapes text for use inside a MarkdownV2 code span
+func EscapeMarkdownCode(text string) string {
+	return markdownCodeEscaper.Replace(text)
+}
 
-🔄 Account linking system is in development.
-Contact admin for manual verification.`,
+func markdownEscapes(chars string) []string {
+	pairs := make([]string, 0, 2*len(chars))
+	for _, c := range chars {
+		pairs = append(pairs, string(c), "\\"+string(c))
 	}
-	
-	if tmpl, ok := templates[name]; ok {
-		return tmpl
-	}
-	return ""
-}
\ No newline at end of file
+	return pairs
+}
@@ -0,0 +1,49 @@
+package bot_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"disciplo/src/bot"
+)
+
+func TestLoadTemplatesRejectsInvalidMarkdown(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		template string
+		problem  string
+	}{
+		{"unescaped dot", "Use /dashboard to log in.", "unescaped '.'"},
+		{"unescaped dash", "- item", "unescaped '-'"},
+		{"unescaped parenthesis", "Members (all)", "unescaped '('"},
+		{"unclosed bold", "*Help", `unclosed "*"`},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "help.md"), []byte(s.template), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := bot.LoadTemplates(dir)
+			if err == nil || !strings.Contains(err.Error(), s.problem) {
+				t.Errorf("LoadTemplates() = %v, want an error mentioning %q", err, s.problem)
+			}
+		})
+	}
+}
+
+func TestLoadTemplatesAcceptsEscapedMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	help := "*Help*\n\n• `/status` \\- show your status\n• [Dashboard](http://localhost/dashboard)\n"
+	if err := os.WriteFile(filepath.Join(dir, "help.md"), []byte(help), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bot.LoadTemplates(dir); err != nil {
+		t.Fatal(err)
+	}
+}
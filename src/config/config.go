@@ -17,6 +17,8 @@ type Config struct {
 	AdminPassword string
 	BotToken     string
 	BotUsername  string
+	BotMode      string
+	BotWebhookSecret string
 	SMTPHost     string
 	SMTPPort     string
 	SMTPUsername string
@@ -53,6 +55,8 @@ func Load() (*Config, error) {
 		AdminPassword: getEnvRequired("ADMIN_PASSWORD"),
 		BotToken:      getEnvRequired("BOT_TOKEN"),
 		BotUsername:   getEnv("BOT_USERNAME", ""),
+		BotMode:       getEnv("BOT_MODE", "polling"),
+		BotWebhookSecret: getEnv("BOT_WEBHOOK_SECRET", ""),
 		SMTPHost:      getEnv("SMTP_HOST", ""),
 		SMTPPort:      getEnv("SMTP_PORT", "587"),
 		SMTPUsername:  getEnv("SMTP_USER", getEnv("SMTP_USERNAME", "")),
@@ -61,9 +65,30 @@ func Load() (*Config, error) {
 		DBPath:        getEnv("DB_PATH", "pb_data"),
 	}
 
+	if cfg.BotMode != "polling" && cfg.BotMode != "webhook" {
+		return nil, fmt.Errorf("BOT_MODE must be \"polling\" or \"webhook\", got %q", cfg.BotMode)
+	}
+	if cfg.BotMode == "webhook" && !validWebhookSecret(cfg.BotWebhookSecret) {
+		return nil, fmt.Errorf("BOT_WEBHOOK_SECRET must be 16-256 characters of A-Z, a-z, 0-9, _ and - in webhook mode")
+	}
+
 	return cfg, nil
 }
 
+// validWebhookSecret checks the secret against Telegram's secret_token rules, plus a
+// minimum length since it also guards the webhook route
+func validWebhookSecret(secret string) bool {
+	if len(secret) < 16 || len(secret) > 256 {
+		return false
+	}
+	for _, r := range secret {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
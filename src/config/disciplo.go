@@ -13,6 +13,9 @@ type DisciploConfig struct {
 	Email        EmailConfig        `toml:"email"`
 	Admin        AdminConfig        `toml:"admin"`
 	Auth         AuthConfig         `toml:"auth"`
+	Telegram     TelegramConfig     `toml:"telegram"`
+	Web          WebConfig          `toml:"web"`
+	Communities  CommunitiesConfig  `toml:"communities"`
 }
 
 type GeneralConfig struct {
@@ -57,13 +60,17 @@ type EmailTemplates struct {
 	NewRequest          string `toml:"new_request"`
 	RegistrationReceived string `toml:"registration_received"`
 	ApprovalWelcome     string `toml:"approval_welcome"`
+	Rejection           string `toml:"rejection"`
 }
 
 type AdminConfig struct {
-	RequestsPage        string `toml:"requests_page"`
-	BulkOperations      bool   `toml:"bulk_operations"`
-	IndividualReview    bool   `toml:"individual_review_only"`
-	RejectionReasons    bool   `toml:"rejection_reasons"`
+	RequestsPage        string   `toml:"requests_page"`
+	BulkOperations      bool     `toml:"bulk_operations"`
+	IndividualReview    bool     `toml:"individual_review_only"`
+	RejectionReasons    bool     `toml:"rejection_reasons"`
+	RejectionOptions    []string `toml:"rejection_reason_options"`
+	RejectionEmail      bool     `toml:"rejection_email"`
+	ReapplyCooldownDays int      `toml:"reapply_cooldown_days"`
 }
 
 type AuthConfig struct {
@@ -72,6 +79,27 @@ type AuthConfig struct {
 	ShowWaitMessageWhenPending bool `toml:"show_wait_message_when_pending"`
 }
 
+type TelegramConfig struct {
+	ReconcileSchedule string `toml:"reconcile_schedule"`
+	TemplatePath      string `toml:"template_path"`
+}
+
+type WebConfig struct {
+	TemplatePath string `toml:"template_path"`
+}
+
+type CommunitiesConfig struct {
+	LocalFallback string         `toml:"local_fallback"`
+	Rotation      RotationConfig `toml:"rotation"`
+}
+
+type RotationConfig struct {
+	Schedule     string `toml:"schedule"`
+	TenureDays   int    `toml:"tenure_days"`
+	Policy       string `toml:"policy"`
+	ResponseDays int    `toml:"response_days"`
+}
+
 // LoadDisciploConfig loads configuration from disciplo.toml file
 func LoadDisciploConfig() (*DisciploConfig, error) {
 	var config DisciploConfig
@@ -126,6 +154,7 @@ func getDefaultConfig() *DisciploConfig {
 				NewRequest:          "new_request.md",
 				RegistrationReceived: "registration_received.md",
 				ApprovalWelcome:     "approval_welcome.md",
+				Rejection:           "rejection.md",
 			},
 		},
 		Admin: AdminConfig{
@@ -133,11 +162,28 @@ func getDefaultConfig() *DisciploConfig {
 			BulkOperations:      false,
 			IndividualReview:    true,
 			RejectionReasons:    false,
+			RejectionEmail:      true,
+			ReapplyCooldownDays: 30,
 		},
 		Auth: AuthConfig{
 			PendingUsersCanLogin:       false,
 			ShowSignupLinkWhenNoUser:   true,
 			ShowWaitMessageWhenPending: true,
 		},
+		Telegram: TelegramConfig{
+			ReconcileSchedule: "0 4 * * *",
+			TemplatePath:      "pb_public/bot_templates",
+		},
+		Web: WebConfig{
+			TemplatePath: "pb_public",
+		},
+		Communities: CommunitiesConfig{
+			Rotation: RotationConfig{
+				Schedule:     "0 9 * * *",
+				TenureDays:   75,
+				Policy:       "round_robin",
+				ResponseDays: 3,
+			},
+		},
 	}
 }
\ No newline at end of file
@@ -0,0 +1,287 @@
+package email
+
+import (
+	"bytes"
+	"disciplo/src/config"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// TemplateData holds every variable documented in templates/README.md
+type TemplateData struct {
+	// Global variables
+	AppName     string
+	AdminEmail  string
+	BotUsername string
+	Host        string
+	Date        string
+
+	// User data variables
+	Name              string
+	Email             string
+	DateOfBirth       string
+	City              string
+	Location          string
+	JobField          string
+	Interests         []string
+	WhyJoin           string
+	ProfilePictureURL string
+
+	// Request-specific variables
+	RequestID      string
+	Status         string
+	SubmissionDate string
+	ApprovalDate   string
+	StatusURL      string
+
+	// Rejection-specific variables
+	RejectionReason string
+	RejectionDate   string
+	ReapplyDate     string
+
+	// Admin-specific variables
+	ReviewURL    string
+	ApprovalURL  string
+	DashboardURL string
+
+	// Bot integration variables
+	TelegramToken string
+	BotDeepLink   string
+}
+
+// RenderedEmail is a template rendered into a ready-to-send message body
+type RenderedEmail struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+const dateFormat = "2006-01-02 15:04"
+
+// NewTemplateData builds the template variables for a registration request
+func NewTemplateData(cfg *config.Config, disciploConfig *config.DisciploConfig, request *core.Record) *TemplateData {
+	appName := disciploConfig.General.AppName
+	if appName == "" {
+		appName = cfg.AppName
+	}
+
+	data := &TemplateData{
+		AppName:      appName,
+		AdminEmail:   cfg.AdminEmail,
+		BotUsername:  cfg.BotUsername,
+		Host:         cfg.Host,
+		Date:         time.Now().Format(dateFormat),
+		DashboardURL: cfg.Host + "/dashboard",
+	}
+
+	if request == nil {
+		return data
+	}
+
+	data.Name = request.GetString("name")
+	data.Email = request.GetString("email")
+	data.City = request.GetString("city")
+	data.Location = request.GetString("location")
+	data.JobField = request.GetString("job_field")
+	data.Interests = request.GetStringSlice("interests")
+	data.WhyJoin = request.GetString("why_join")
+	data.RequestID = request.Id
+	data.Status = request.GetString("status")
+	data.ReviewURL = cfg.Host + "/admin/requests#request-" + request.Id
+	data.ApprovalURL = cfg.Host + "/admin/requests?approve=" + request.Id
+
+	if dob := request.GetDateTime("date_of_birth"); !dob.IsZero() {
+		data.DateOfBirth = dob.Time().Format("2006-01-02")
+	}
+	if created := request.GetDateTime("created"); !created.IsZero() {
+		data.SubmissionDate = created.Time().Format(dateFormat)
+	}
+	if approved := request.GetDateTime("approved_at"); !approved.IsZero() {
+		data.ApprovalDate = approved.Time().Format(dateFormat)
+	}
+	if rejected := request.GetDateTime("rejected_at"); !rejected.IsZero() {
+		data.RejectionReason = request.GetString("rejection_reason")
+		data.RejectionDate = rejected.Time().Format(dateFormat)
+		if days := disciploConfig.Admin.ReapplyCooldownDays; days > 0 {
+			data.ReapplyDate = rejected.Time().AddDate(0, 0, days).Format("2006-01-02")
+		}
+	}
+	if statusToken := request.GetString("status_token"); statusToken != "" {
+		data.StatusURL = cfg.Host + "/request-status/" + statusToken
+	}
+	if request.GetString("profile_picture") != "" {
+		data.ProfilePictureURL = cfg.Host + "/api/admin/requests/" + request.Id + "/picture"
+	}
+
+	return data
+}
+
+// SetTelegramToken sets the bot token and the matching deeplink
+func (d *TemplateData) SetTelegramToken(token string) {
+	d.TelegramToken = token
+	d.BotDeepLink = fmt.Sprintf("https://t.me/%s?start=%s", d.BotUsername, token)
+}
+
+// markdownEscaper backslash-escapes the characters Markdown gives a meaning to
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
+	`[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `#`, `\#`, `+`, `\+`,
+	`-`, `\-`, `.`, `\.`, `!`, `\!`, `|`, `\|`, `<`, `\<`, `>`, `\>`,
+	`~`, `\~`, `=`, `\=`,
+)
+
+// markdownEscaped returns a copy of the data with the free-text answers of the
+// applicant and the reviewer escaped, so they render as typed. The email is typed
+// by the applicant too, and its ".", "_" and "+" would otherwise format the text.
+func (d *TemplateData) markdownEscaped() *TemplateData {
+	escaped := *d
+	escaped.Name = markdownEscaper.Replace(d.Name)
+	escaped.Email = markdownEscaper.Replace(d.Email)
+	escaped.City = markdownEscaper.Replace(d.City)
+	escaped.WhyJoin = markdownEscaper.Replace(d.WhyJoin)
+	escaped.RejectionReason = markdownEscaper.Replace(d.RejectionReason)
+	return &escaped
+}
+
+// templateFuncs are the helpers available inside email templates
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"formatDate": func(layout, value string) string {
+		t, err := time.Parse(dateFormat, value)
+		if err != nil {
+			return value
+		}
+		return t.Format(layout)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	// Raw HTML is NOT enabled, so any markup typed by applicants is dropped
+	goldmark.WithRendererOptions(html.WithHardWraps()),
+)
+
+// RenderTemplate executes a Go-templated Markdown email and converts it to HTML
+// (wrapped in the shared layout) plus a plain-text alternative. The subject is
+// taken from the first "# " heading.
+func RenderTemplate(disciploConfig *config.DisciploConfig, name string, data *TemplateData) (*RenderedEmail, error) {
+	content, err := loadTemplateFile(disciploConfig.Email.TemplatePath, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+	}
+
+	// The plain-text alternative and subject use the values as typed; the HTML body
+	// is rendered from escaped values so applicants can't inject Markdown links,
+	// headings or images into emails sent to admins
+	var source bytes.Buffer
+	if err := tmpl.Execute(&source, data); err != nil {
+		return nil, fmt.Errorf("failed to execute email template %s: %w", name, err)
+	}
+
+	var escapedSource bytes.Buffer
+	if err := tmpl.Execute(&escapedSource, data.markdownEscaped()); err != nil {
+		return nil, fmt.Errorf("failed to execute email template %s: %w", name, err)
+	}
+
+	var body bytes.Buffer
+	if err := markdown.Convert(escapedSource.Bytes(), &body); err != nil {
+		return nil, fmt.Errorf("failed to convert email template %s: %w", name, err)
+	}
+
+	subject := extractSubject(source.String())
+	if subject == "" {
+		subject = data.AppName
+	}
+
+	var layout bytes.Buffer
+	if err := layoutTemplate.Execute(&layout, map[string]interface{}{
+		"Subject": subject,
+		"AppName": data.AppName,
+		"Content": htmltemplate.HTML(body.String()),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to execute email layout: %w", err)
+	}
+
+	return &RenderedEmail{
+		Subject: subject,
+		HTML:    layout.String(),
+		Text:    strings.TrimSpace(source.String()),
+	}, nil
+}
+
+// loadTemplateFile looks up an email template in the configured path, falling back to
+// the locations used when running from the build directory or the repository root
+func loadTemplateFile(templatePath, name string) ([]byte, error) {
+	if templatePath == "" {
+		templatePath = filepath.Join("templates", "emails")
+	}
+
+	paths := []string{
+		filepath.Join(templatePath, name),
+		filepath.Join("..", templatePath, name),
+		filepath.Join("build", templatePath, name),
+	}
+
+	for _, path := range paths {
+		if content, err := os.ReadFile(path); err == nil {
+			return content, nil
+		}
+	}
+
+	return nil, fmt.Errorf("email template %s not found in %s", name, templatePath)
+}
+
+// extractSubject returns the text of the first top-level Markdown heading
+func extractSubject(source string) string {
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
+
+var layoutTemplate = htmltemplate.Must(htmltemplate.New("layout").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>{{.Subject}}</title>
+	<style>
+		body { font-family: -apple-system, sans-serif; line-height: 1.6; color: #333; }
+		.container { max-width: 600px; margin: 0 auto; padding: 20px; }
+		.content { background: white; padding: 30px; border: 1px solid #e9ecef; border-radius: 8px 8px 0 0; }
+		.content a { color: #0088cc; }
+		.content blockquote { margin: 0; padding: 10px 20px; background: #f8f9fa; border-left: 4px solid #e9ecef; font-style: italic; }
+		.content hr { border: none; border-top: 1px solid #e9ecef; margin: 24px 0; }
+		.footer { background: #f8f9fa; padding: 20px; text-align: center; font-size: 14px; color: #6c757d; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<div class="content">
+			{{.Content}}
+		</div>
+		<div class="footer">
+			<p>This is an automated message from {{.AppName}}</p>
+		</div>
+	</div>
+</body>
+</html>`))
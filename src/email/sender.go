@@ -3,69 +3,62 @@ package email
 import (
 	"bytes"
 	"disciplo/src/config"
+	"disciplo/src/static"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"net/mail"
-	"os"
-	"path/filepath"
 	
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/mailer"
 )
 
 // SendNewRegistrationNotification sends email to admin when new registration request is submitted
-func SendNewRegistrationNotification(app core.App, adminEmail, applicantName, applicantEmail string) error {
-	// Send email using PocketBase mailer
-	message := &mailer.Message{
-		From: mail.Address{
-			Address: app.Settings().Meta.SenderAddress,
-			Name:    app.Settings().Meta.SenderName,
-		},
-		To: []mail.Address{{
-			Address: adminEmail,
-		}},
-		Subject: "New Membership Request - " + applicantName,
-		HTML: fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-	<style>
-		body { font-family: -apple-system, sans-serif; line-height: 1.6; color: #333; }
-		.container { max-width: 600px; margin: 0 auto; padding: 20px; }
-		.header { background: #f8f9fa; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
-		.content { background: white; padding: 30px; border: 1px solid #e9ecef; }
-		.button { display: inline-block; padding: 12px 24px; background: #0088cc; color: white; text-decoration: none; border-radius: 6px; margin: 20px 0; }
-		.footer { background: #f8f9fa; padding: 20px; text-align: center; font-size: 14px; color: #6c757d; }
-	</style>
-</head>
-<body>
-	<div class="container">
-		<div class="header">
-			<h1>New Membership Request</h1>
-		</div>
-		<div class="content">
-			<p>Hello Admin,</p>
-			<p>A new membership request has been submitted:</p>
-			<p><strong>Name:</strong> %s<br>
-			<strong>Email:</strong> %s</p>
-			<p>Please review and approve/reject this request in the admin dashboard.</p>
-		</div>
-		<div class="footer">
-			<p>This is an automated message from Disciplo</p>
-		</div>
-	</div>
-</body>
-</html>
-		`, applicantName, applicantEmail),
+func SendNewRegistrationNotification(app core.App, disciploConfig *config.DisciploConfig, adminEmail string, data *TemplateData) error {
+	// Admin links point to the admin area rather than the member dashboard
+	adminData := *data
+	adminData.DashboardURL = adminData.Host + "/admin/dashboard"
+
+	rendered, err := RenderTemplate(disciploConfig, disciploConfig.Email.Templates.NewRequest, &adminData)
+	if err != nil {
+		return err
 	}
 
-	return app.NewMailClient().Send(message)
+	return sendRendered(app, adminEmail, rendered)
+}
+
+// SendRegistrationReceived sends the submission confirmation to the applicant
+func SendRegistrationReceived(app core.App, disciploConfig *config.DisciploConfig, data *TemplateData) error {
+	rendered, err := RenderTemplate(disciploConfig, disciploConfig.Email.Templates.RegistrationReceived, data)
+	if err != nil {
+		return err
+	}
+
+	return sendRendered(app, data.Email, rendered)
 }
 
 // SendApprovalWelcome sends welcome email to approved user with bot link
-func SendApprovalWelcome(app core.App, userEmail, userName, botUsername, token string) error {
-	botLink := fmt.Sprintf("https://t.me/%s?start=%s", botUsername, token)
+func SendApprovalWelcome(app core.App, disciploConfig *config.DisciploConfig, data *TemplateData) error {
+	rendered, err := RenderTemplate(disciploConfig, disciploConfig.Email.Templates.ApprovalWelcome, data)
+	if err != nil {
+		return err
+	}
 
+	return sendRendered(app, data.Email, rendered)
+}
+
+// SendRejection notifies the applicant that their request was rejected
+func SendRejection(app core.App, disciploConfig *config.DisciploConfig, data *TemplateData) error {
+	rendered, err := RenderTemplate(disciploConfig, disciploConfig.Email.Templates.Rejection, data)
+	if err != nil {
+		return err
+	}
+
+	return sendRendered(app, data.Email, rendered)
+}
+
+// sendRendered sends a rendered template with both HTML and plain-text bodies
+func sendRendered(app core.App, to string, rendered *RenderedEmail) error {
 	// Send email using PocketBase mailer
 	message := &mailer.Message{
 		From: mail.Address{
@@ -73,44 +66,11 @@ func SendApprovalWelcome(app core.App, userEmail, userName, botUsername, token s
 			Name:    app.Settings().Meta.SenderName,
 		},
 		To: []mail.Address{{
-			Address: userEmail,
+			Address: to,
 		}},
-		Subject: "Welcome to Disciplo - Your Application was Approved!",
-		HTML: fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-	<style>
-		body { font-family: -apple-system, sans-serif; line-height: 1.6; color: #333; }
-		.container { max-width: 600px; margin: 0 auto; padding: 20px; }
-		.header { background: #f8f9fa; padding: 20px; text-align: center; border-radius: 8px 8px 0 0; }
-		.content { background: white; padding: 30px; border: 1px solid #e9ecef; }
-		.button { display: inline-block; padding: 12px 24px; background: #28a745; color: white; text-decoration: none; border-radius: 6px; margin: 20px 0; }
-		.footer { background: #f8f9fa; padding: 20px; text-align: center; font-size: 14px; color: #6c757d; }
-	</style>
-</head>
-<body>
-	<div class="container">
-		<div class="header">
-			<h1>Welcome to Disciplo!</h1>
-		</div>
-		<div class="content">
-			<p>Hello %s,</p>
-			<p>Congratulations! Your membership application has been approved.</p>
-			<p>To complete your setup, please connect with our Telegram bot:</p>
-			<p style="text-align: center;">
-				<a href="%s" class="button">Connect to Telegram Bot</a>
-			</p>
-			<p>Once connected, you'll gain access to our community groups and platform.</p>
-			<p>If the button doesn't work, copy this link: <br>%s</p>
-		</div>
-		<div class="footer">
-			<p>This is an automated message from Disciplo</p>
-		</div>
-	</div>
-</body>
-</html>
-		`, userName, botLink, botLink),
+		Subject: rendered.Subject,
+		HTML:    rendered.HTML,
+		Text:    rendered.Text,
 	}
 
 	return app.NewMailClient().Send(message)
@@ -119,8 +79,7 @@ func SendApprovalWelcome(app core.App, userEmail, userName, botUsername, token s
 // SendAdminInvitation sends welcome email to admin with Telegram link
 func SendAdminInvitation(app core.App, cfg *config.Config, telegramLink string) error {
 	// Load email template
-	templatePath := filepath.Join("pb_public", "email_templates", "admin_invitation.html")
-	content, err := os.ReadFile(templatePath)
+	content, err := fs.ReadFile(static.FS(static.DefaultOverrideDir), "email_templates/admin_invitation.html")
 	if err != nil {
 		// Fallback to default template
 		content = []byte(getDefaultAdminInvitationTemplate())
@@ -1,20 +1,17 @@
 package main
 
 import (
+	"disciplo/src/bot"
 	"disciplo/src/config"
 	"disciplo/src/email"
 	_ "disciplo/src/migrations"
+	"disciplo/src/permissions"
 	"disciplo/src/web"
-	"bytes"
 	"fmt"
-	"html/template"
-	"os"
-	"path/filepath"
 	"log"
 	"strings"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
@@ -91,6 +88,7 @@ func main() {
 				// Generate and save admin token
 				token, _ := gonanoid.New(21)
 				admin.Set("telegram_token", token)
+				admin.Set("telegram_token_created", time.Now())
 				if err := e.App.Save(admin); err != nil {
 					log.Printf("⚠️  Failed to save admin telegram token: %v", err)
 				}
@@ -125,11 +123,18 @@ func main() {
 		return e.Next()
 	})
 
+	// Keep user roles in sync with the admin flag and group admins
+	permissions.RegisterHooks(app)
+
 	// Setup web routes
-	web.SetupRoutes(app, cfg)
+	if err := web.SetupRoutes(app, cfg); err != nil {
+		log.Fatalf("Failed to load page templates: %v", err)
+	}
 
 	// Start Telegram bot
-	go startBot(app, cfg)
+	if err := bot.Setup(app, cfg); err != nil {
+		log.Fatalf("Failed to start Telegram bot: %v", err)
+	}
 
 	log.Println("🚀 Starting Disciplo server...")
 	log.Printf("🌐 Visit %s for dashboard", cfg.Host)
@@ -140,196 +145,3 @@ func main() {
 	}
 }
 
-
-func startBot(app core.App, cfg *config.Config) {
-	bot, err := tgbotapi.NewBotAPI(cfg.BotToken)
-	if err != nil {
-		log.Printf("❌ Bot failed to start: %v", err)
-		log.Printf("💡 Check BOT_TOKEN in .env file")
-		return
-	}
-
-	if cfg.DevMode {
-		bot.Debug = true
-	}
-
-	log.Printf("✅ Telegram bot ready: @%s", bot.Self.UserName)
-
-	// Update .env reminder if needed
-	if cfg.BotUsername == "" || cfg.BotUsername == "your_bot_username" {
-		log.Printf("💡 Update BOT_USERNAME in .env to: %s", bot.Self.UserName)
-	}
-
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := bot.GetUpdatesChan(u)
-
-	for update := range updates {
-		if update.Message == nil || !update.Message.IsCommand() {
-			continue
-		}
-
-		switch update.Message.Command() {
-		case "start":
-			handleStartCommand(bot, update.Message, app, cfg)
-		case "help":
-			handleHelpCommand(bot, update.Message)
-		case "status":
-			handleStatusCommand(bot, update.Message)
-		default:
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Unknown command. Use /help for available commands.")
-			bot.Send(msg)
-		}
-	}
-}
-
-func handleStartCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message, app core.App, cfg *config.Config) {
-	args := message.CommandArguments()
-	var response string
-	
-	if args != "" {
-		// Find user by telegram_token (works for both admin and regular users)
-		user, err := app.FindFirstRecordByFilter("users", "telegram_token = {:token}", map[string]interface{}{
-			"token": args,
-		})
-		
-		if err != nil || user == nil {
-			response = "❌ **Invalid or Expired Token**\n\nThe token you used is not valid or has expired. Please contact your administrator for a new invitation link."
-			log.Printf("❌ Invalid token used: %s", args)
-		} else {
-			// Check token expiration (24 hours for regular users, 1 hour for fresh admin tokens)
-			tokenCreated := user.GetDateTime("telegram_token_created")
-			isAdmin := user.GetBool("admin")
-			
-			var tokenExpired bool
-			if isAdmin {
-				// Admin tokens expire after 1 hour
-				tokenExpired = time.Since(tokenCreated.Time()) > time.Hour
-			} else {
-				// User tokens expire after 24 hours  
-				tokenExpired = time.Since(tokenCreated.Time()) > 24*time.Hour
-			}
-			
-			if tokenExpired {
-				response = "❌ **Token Expired**\n\nYour invitation token has expired. Please request a new invitation link from your administrator."
-				log.Printf("❌ Expired token used: %s (created: %v)", args, tokenCreated)
-			} else {
-			
-			// Update user with Telegram information
-			user.Set("telegram_id", fmt.Sprintf("%d", message.From.ID))
-			user.Set("telegram_name", message.From.UserName)
-			user.Set("verified", true) // Now verified since Telegram is linked
-			user.Set("telegram_token", "") // Clear the token after successful linking
-			
-			if err := app.Save(user); err != nil {
-				log.Printf("❌ Failed to update user telegram info: %v", err)
-				response = "❌ **Connection Failed**\n\nThere was an error linking your account. Please try again or contact support."
-			} else {
-				// Determine user role for message
-				isAdmin := user.GetBool("admin")
-				userName := user.GetString("name")
-				
-				// Use template for connection success message
-				templateData := struct {
-					FirstName string
-					IsAdmin bool
-				}{
-					FirstName: message.From.FirstName,
-					IsAdmin: isAdmin,
-				}
-				
-				if templateMsg, err := loadBotTemplate("connection_success.md", templateData); err == nil {
-					response = templateMsg
-				} else {
-					// Fallback message based on role
-					if isAdmin {
-						response = fmt.Sprintf("🎉 **Telegram Connected Successfully!**\n\nWelcome %s! Your Telegram account has been linked to Disciplo.\n\n✅ **Account Status**: Accepted\n👑 **Role**: Administrator\n🌐 **Community**: Disciplo\n\nYou can now access your dashboard to manage your profile and community settings.",
-							message.From.FirstName)
-					} else {
-						response = fmt.Sprintf("🎉 **Telegram Connected Successfully!**\n\nWelcome %s! Your Telegram account has been linked to Disciplo.\n\n✅ **Account Status**: Verified\n👤 **Role**: Member\n🌐 **Community**: Disciplo\n\nYou are now verified and can access community features.",
-							message.From.FirstName)
-					}
-				}
-				
-				log.Printf("🔗 USER LINKED - Name: %s | Email: %s | Admin: %v | TG_ID: %d | Username: @%s",
-					userName, user.GetString("email"), isAdmin, message.From.ID, message.From.UserName)
-			}
-		}
-		}
-	} else {
-		response = "Welcome to **Disciplo**! 🎉\n\nTo connect your Telegram account, you need an invitation token from the admin.\n\n**How to get access:**\n1. Contact your administrator\n2. Get an invitation link\n3. Click the link to return here with a token"
-	}
-
-	// Add dashboard link logic before creating message
-	dashboardURL := cfg.Host + "/dashboard"
-	if !strings.HasPrefix(cfg.Host, "https://") {
-		// For HTTP URLs, add dashboard link as text
-		response += "\n\n🌐 **Dashboard**: " + dashboardURL
-	}
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, response)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-
-	// Add inline keyboard for dashboard access only if HTTPS
-	if strings.HasPrefix(cfg.Host, "https://") {
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonURL("🌐 Visit Dashboard", dashboardURL),
-			),
-		)
-		msg.ReplyMarkup = keyboard
-	}
-
-	bot.Send(msg)
-}
-
-func handleHelpCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	help := `**Disciplo Bot Commands**
-
-🔗 **/start** - Connect account (requires invitation token)
-❓ **/help** - Show this help message  
-📊 **/status** - Check your account status
-
-**Getting Started:**
-Contact your community admin for an invitation link.`
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, help)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	bot.Send(msg)
-}
-
-func handleStatusCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	response := fmt.Sprintf("📊 **Your Account**\n\n"+
-		"• **Telegram ID:** `%d`\n"+
-		"• **Username:** @%s\n"+
-		"• **Name:** %s %s\n\n"+
-		"🔄 Account linking system is in development.\nContact admin for manual verification.",
-		message.From.ID, message.From.UserName, message.From.FirstName, message.From.LastName)
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, response)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	bot.Send(msg)
-}
-
-// loadBotTemplate loads and renders a bot message template
-func loadBotTemplate(templateName string, data interface{}) (string, error) {
-	// Try to load template from file
-	templatePath := filepath.Join("pb_public", "bot_templates", templateName)
-	content, err := os.ReadFile(templatePath)
-	if err != nil {
-		return "", err
-	}
-	
-	// Parse and execute template
-	tmpl, err := template.New("bot").Parse(string(content))
-	if err != nil {
-		return "", err
-	}
-	
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
-	}
-	
-	return buf.String(), nil
-}
\ No newline at end of file
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// The password hash is moved into the users record on approval and
+		// cleared from the request afterwards, so it can no longer be required
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := requestsCollection.Fields.GetByName("password").(*core.TextField); ok {
+			field.Required = false
+			field.Hidden = true
+		}
+
+		return app.Save(requestsCollection)
+	}, func(app core.App) error {
+		// Revert - make password required and visible again
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := requestsCollection.Fields.GetByName("password").(*core.TextField); ok {
+			field.Required = true
+			field.Hidden = false
+		}
+
+		return app.Save(requestsCollection)
+	}, sortedName("10_make_request_password_optional.go"))
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"disciplo/src/config"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// pictureMimeTypes maps the configured picture formats to their MIME types
+func pictureMimeTypes(formats []string) []string {
+	var mimeTypes []string
+	seen := make(map[string]bool)
+	for _, format := range formats {
+		var mimeType string
+		switch format {
+		case "jpg", "jpeg":
+			mimeType = "image/jpeg"
+		case "png":
+			mimeType = "image/png"
+		default:
+			continue
+		}
+		if !seen[mimeType] {
+			seen[mimeType] = true
+			mimeTypes = append(mimeTypes, mimeType)
+		}
+	}
+	return mimeTypes
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Load disciplo configuration for the allowed formats and size limit
+		disciploConfig, err := config.LoadDisciploConfig()
+		if err != nil {
+			disciploConfig = &config.DisciploConfig{}
+		}
+
+		mimeTypes := pictureMimeTypes(disciploConfig.Registration.Picture.AllowedFormats)
+		if len(mimeTypes) == 0 {
+			mimeTypes = []string{"image/jpeg", "image/png"}
+		}
+
+		maxSize := int64(disciploConfig.Registration.Picture.MaxSizeMB) << 20
+		if maxSize <= 0 {
+			maxSize = core.DefaultFileFieldMaxSize
+		}
+
+		// Configure the profile picture field on requests
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := requestsCollection.Fields.GetByName("profile_picture").(*core.FileField); ok {
+			field.MaxSelect = 1
+			field.MaxSize = maxSize
+			field.MimeTypes = mimeTypes
+			field.Thumbs = []string{"150x150"}
+		}
+
+		if err := app.Save(requestsCollection); err != nil {
+			return err
+		}
+
+		// Add (or configure) the avatar field on users
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		avatarField, ok := usersCollection.Fields.GetByName("avatar").(*core.FileField)
+		if !ok {
+			avatarField = &core.FileField{
+				Id:   "avatar",
+				Name: "avatar",
+			}
+			usersCollection.Fields.Add(avatarField)
+		}
+		avatarField.MaxSelect = 1
+		avatarField.MaxSize = maxSize
+		avatarField.MimeTypes = mimeTypes
+		avatarField.Thumbs = []string{"150x150"}
+
+		return app.Save(usersCollection)
+	}, func(app core.App) error {
+		// Revert: the avatar field may be PocketBase's own default, so we leave it
+		return nil
+	}, sortedName("11_configure_profile_pictures.go"))
+}
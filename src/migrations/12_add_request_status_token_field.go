@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Add status_token field to requests for the public status-check page
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		requestsCollection.Fields.Add(&core.TextField{
+			Id:     "status_token",
+			Name:   "status_token",
+			Hidden: true,
+		})
+		// Tokens are looked up on their own, so no two requests may share one.
+		// Requests from before this migration have none and are left out.
+		requestsCollection.AddIndex("idx_requests_status_token", true, "status_token", "status_token != ''")
+
+		return app.Save(requestsCollection)
+	}, func(app core.App) error {
+		// Revert - remove the field and its index
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		requestsCollection.RemoveIndex("idx_requests_status_token")
+		requestsCollection.Fields.RemoveByName("status_token")
+
+		return app.Save(requestsCollection)
+	}, sortedName("12_add_request_status_token_field.go"))
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Add rejection tracking fields to requests
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		requestsCollection.Fields.Add(
+			&core.RelationField{
+				Id:            "rejected_by",
+				Name:          "rejected_by",
+				CollectionId:  "_pb_users_auth_",
+				CascadeDelete: false,
+			},
+			&core.DateField{
+				Id:   "rejected_at",
+				Name: "rejected_at",
+			},
+			&core.TextField{
+				Id:   "rejection_reason",
+				Name: "rejection_reason",
+			},
+		)
+		requestsCollection.AddIndex("idx_requests_email_status", false, "email, status", "")
+
+		return app.Save(requestsCollection)
+	}, func(app core.App) error {
+		// Revert - remove the rejection fields
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		requestsCollection.RemoveIndex("idx_requests_email_status")
+		requestsCollection.Fields.RemoveByName("rejected_by")
+		requestsCollection.Fields.RemoveByName("rejected_at")
+		requestsCollection.Fields.RemoveByName("rejection_reason")
+
+		return app.Save(requestsCollection)
+	}, sortedName("13_add_request_rejection_fields.go"))
+}
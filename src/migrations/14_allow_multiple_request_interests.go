@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// The registration form sends several interests, but the select field was
+		// created single-valued so only one was ever stored. PocketBase converts the
+		// existing values to JSON arrays when the field becomes multi-valued.
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := requestsCollection.Fields.GetByName("interests").(*core.SelectField); ok {
+			field.MaxSelect = len(field.Values)
+		}
+
+		return app.Save(requestsCollection)
+	}, func(app core.App) error {
+		// Revert - back to a single interest
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := requestsCollection.Fields.GetByName("interests").(*core.SelectField); ok {
+			field.MaxSelect = 1
+		}
+
+		return app.Save(requestsCollection)
+	}, sortedName("14_allow_multiple_request_interests.go"))
+}
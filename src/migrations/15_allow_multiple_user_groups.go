@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// maxUserGroups is the relation limit for communities a user can belong to
+const maxUserGroups = 999
+
+func init() {
+	m.Register(func(app core.App) error {
+		// The groups relation was created single-valued, but a member belongs to
+		// every default community plus their local ones
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := usersCollection.Fields.GetByName("groups").(*core.RelationField); ok {
+			field.MaxSelect = maxUserGroups
+		}
+
+		return app.Save(usersCollection)
+	}, func(app core.App) error {
+		// Revert - back to a single group
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := usersCollection.Fields.GetByName("groups").(*core.RelationField); ok {
+			field.MaxSelect = 1
+		}
+
+		return app.Save(usersCollection)
+	}, sortedName("15_allow_multiple_user_groups.go"))
+}
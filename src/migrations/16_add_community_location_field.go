@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"disciplo/src/config"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Local communities serve one of the registration locations
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		disciploConfig, err := config.LoadDisciploConfig()
+		if err != nil {
+			disciploConfig = &config.DisciploConfig{}
+		}
+
+		locationOptions := []string{"Lazio", "Lombardia", "Piemonte", "Veneto", "Toscana"}
+		if len(disciploConfig.Registration.Locations.Options) > 0 {
+			locationOptions = disciploConfig.Registration.Locations.Options
+		}
+
+		communitiesCollection.Fields.Add(&core.SelectField{
+			Id:        "location",
+			Name:      "location",
+			Values:    locationOptions,
+			MaxSelect: 1,
+		})
+
+		return app.Save(communitiesCollection)
+	}, func(app core.App) error {
+		// Revert - remove the field
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.RemoveByName("location")
+
+		return app.Save(communitiesCollection)
+	}, sortedName("16_add_community_location_field.go"))
+}
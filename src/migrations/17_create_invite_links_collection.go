@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Check if invite_links collection already exists
+		if collection, _ := app.FindCollectionByNameOrId("invite_links"); collection != nil {
+			return nil
+		}
+
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		// One single-use Telegram invite link issued to a member for a community
+		collection := core.NewBaseCollection("invite_links")
+		collection.Fields = core.FieldsList{
+			&core.RelationField{
+				Id:            "user",
+				Name:          "user",
+				CollectionId:  "_pb_users_auth_",
+				CascadeDelete: true,
+				Required:      true,
+			},
+			&core.RelationField{
+				Id:            "community",
+				Name:          "community",
+				CollectionId:  communitiesCollection.Id,
+				CascadeDelete: true,
+				Required:      true,
+			},
+			&core.TextField{
+				Id:       "link",
+				Name:     "link",
+				Required: true,
+			},
+			&core.SelectField{
+				Id:        "status",
+				Name:      "status",
+				Required:  true,
+				Values:    []string{"issued", "consumed", "revoked"},
+				MaxSelect: 1,
+			},
+			&core.DateField{
+				Id:   "expires_at",
+				Name: "expires_at",
+			},
+			&core.DateField{
+				Id:   "consumed_at",
+				Name: "consumed_at",
+			},
+			&core.AutodateField{
+				Id:       "created",
+				Name:     "created",
+				OnCreate: true,
+				OnUpdate: false,
+			},
+			&core.AutodateField{
+				Id:       "updated",
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		}
+		collection.AddIndex("idx_invite_links_user_community", false, "user, community", "")
+		collection.AddIndex("idx_invite_links_link", true, "link", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		// Revert - delete collection
+		if collection, _ := app.FindCollectionByNameOrId("invite_links"); collection != nil {
+			return app.Delete(collection)
+		}
+		return nil
+	}, sortedName("17_create_invite_links_collection.go"))
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Add member_count to communities, kept in sync with the Telegram chat by the bot
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.Add(&core.NumberField{
+			Id:      "member_count",
+			Name:    "member_count",
+			OnlyInt: true,
+		})
+
+		return app.Save(communitiesCollection)
+	}, func(app core.App) error {
+		// Revert - remove the field
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.RemoveByName("member_count")
+
+		return app.Save(communitiesCollection)
+	}, sortedName("18_add_community_member_count_field.go"))
+}
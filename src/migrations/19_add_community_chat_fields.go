@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Store the public username and kind of the linked Telegram chat
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.Add(
+			&core.TextField{
+				Id:   "telegram_username",
+				Name: "telegram_username",
+			},
+			&core.SelectField{
+				Id:        "telegram_type",
+				Name:      "telegram_type",
+				Values:    []string{"group", "supergroup", "channel"},
+				MaxSelect: 1,
+			},
+		)
+
+		return app.Save(communitiesCollection)
+	}, func(app core.App) error {
+		// Revert - remove the fields
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.RemoveByName("telegram_username")
+		communitiesCollection.Fields.RemoveByName("telegram_type")
+
+		return app.Save(communitiesCollection)
+	}, sortedName("19_add_community_chat_fields.go"))
+}
@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// userProfileFields are copied from the request a user was approved from
+var userProfileFields = []string{"city", "location", "job_field", "interests"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Members keep the profile captured at registration, plus the settings of
+		// their card in the member directory
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+
+		// Reuse the request options so every approved value is valid on the user
+		usersCollection.Fields.Add(&core.TextField{
+			Id:   "city",
+			Name: "city",
+		})
+		for _, name := range []string{"location", "job_field", "interests"} {
+			source, ok := requestsCollection.Fields.GetByName(name).(*core.SelectField)
+			if !ok {
+				continue
+			}
+			usersCollection.Fields.Add(&core.SelectField{
+				Id:        name,
+				Name:      name,
+				Values:    append([]string(nil), source.Values...),
+				MaxSelect: source.MaxSelect,
+			})
+		}
+
+		usersCollection.Fields.Add(&core.BoolField{
+			Id:   "directory_visible",
+			Name: "directory_visible",
+		})
+		directoryFields := []string{"email", "city", "location", "job_field", "interests", "telegram"}
+		usersCollection.Fields.Add(&core.SelectField{
+			Id:        "directory_fields",
+			Name:      "directory_fields",
+			Values:    directoryFields,
+			MaxSelect: len(directoryFields),
+		})
+
+		if err := app.Save(usersCollection); err != nil {
+			return err
+		}
+
+		// Backfill members approved before the fields existed
+		requests, err := app.FindAllRecords("requests", dbx.HashExp{"status": "approved"}, dbx.Not(dbx.HashExp{"created_user_id": ""}))
+		if err != nil {
+			return err
+		}
+		for _, request := range requests {
+			user, err := app.FindRecordById("users", request.GetString("created_user_id"))
+			if err != nil {
+				continue
+			}
+			for _, field := range userProfileFields {
+				user.Set(field, request.Get(field))
+			}
+			if err := app.SaveNoValidate(user); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		// Revert - remove the fields
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		for _, field := range append(userProfileFields, "directory_visible", "directory_fields") {
+			usersCollection.Fields.RemoveByName(field)
+		}
+
+		return app.Save(usersCollection)
+	}, sortedName("20_add_user_profile_fields.go"))
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Archived communities are kept with their history but no longer get members
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.Add(&core.BoolField{
+			Id:   "archived",
+			Name: "archived",
+		})
+
+		return app.Save(communitiesCollection)
+	}, func(app core.App) error {
+		// Revert - remove the field
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection.Fields.RemoveByName("archived")
+
+		return app.Save(communitiesCollection)
+	}, sortedName("21_add_community_archived_field.go"))
+}
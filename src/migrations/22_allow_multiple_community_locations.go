@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// A local community covers a list of regions. PocketBase converts the
+		// existing single values to JSON arrays when the field becomes multi-valued.
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := communitiesCollection.Fields.GetByName("location").(*core.SelectField); ok {
+			field.MaxSelect = len(field.Values)
+		}
+
+		return app.Save(communitiesCollection)
+	}, func(app core.App) error {
+		// Revert - back to a single region
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		if field, ok := communitiesCollection.Fields.GetByName("location").(*core.SelectField); ok {
+			field.MaxSelect = 1
+		}
+
+		return app.Save(communitiesCollection)
+	}, sortedName("22_allow_multiple_community_locations.go"))
+}
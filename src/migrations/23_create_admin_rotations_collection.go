@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Check if admin_rotations collection already exists
+		if collection, _ := app.FindCollectionByNameOrId("admin_rotations"); collection != nil {
+			return nil
+		}
+
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		// Members volunteering to be their community's next group admin
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		usersCollection.Fields.Add(&core.BoolField{
+			Id:   "admin_volunteer",
+			Name: "admin_volunteer",
+		})
+		if err := app.Save(usersCollection); err != nil {
+			return err
+		}
+
+		// One proposed handover of a community's group admin role. The history of
+		// confirmed rotations drives the round-robin policy.
+		collection := core.NewBaseCollection("admin_rotations")
+		collection.Fields = core.FieldsList{
+			&core.RelationField{
+				Id:            "community",
+				Name:          "community",
+				CollectionId:  communitiesCollection.Id,
+				CascadeDelete: true,
+				Required:      true,
+			},
+			&core.RelationField{
+				Id:           "outgoing",
+				Name:         "outgoing",
+				CollectionId: "_pb_users_auth_",
+			},
+			&core.RelationField{
+				Id:            "proposed",
+				Name:          "proposed",
+				CollectionId:  "_pb_users_auth_",
+				CascadeDelete: true,
+				Required:      true,
+			},
+			&core.TextField{
+				Id:   "policy",
+				Name: "policy",
+			},
+			&core.SelectField{
+				Id:        "status",
+				Name:      "status",
+				Required:  true,
+				Values:    []string{"proposed", "confirmed", "declined", "expired"},
+				MaxSelect: 1,
+			},
+			&core.DateField{
+				Id:   "decided_at",
+				Name: "decided_at",
+			},
+			&core.AutodateField{
+				Id:       "created",
+				Name:     "created",
+				OnCreate: true,
+				OnUpdate: false,
+			},
+			&core.AutodateField{
+				Id:       "updated",
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		}
+		collection.AddIndex("idx_admin_rotations_community_status", false, "community, status", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		// Revert - delete collection and the volunteer flag
+		if collection, _ := app.FindCollectionByNameOrId("admin_rotations"); collection != nil {
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		usersCollection.Fields.RemoveByName("admin_volunteer")
+
+		return app.Save(usersCollection)
+	}, sortedName("23_create_admin_rotations_collection.go"))
+}
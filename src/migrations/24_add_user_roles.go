@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// userRoles are the values of the users role select, from most to least privileged
+var userRoles = []string{"superadmin", "reviewer", "community_admin", "member"}
+
+// Collection API rules mirroring the permissions package. Reviewers without scoped
+// communities review every request; scoped reviewers and community admins only
+// those from the regions of their communities. Community admins manage their own
+// communities but can't change their type, regions or archived state. Empty
+// relations are guarded explicitly, since the rules treat two empty values as equal.
+const (
+	requestsReviewRule = `@request.auth.role = "superadmin" || ` +
+		`(@request.auth.role = "reviewer" && @request.auth.role_communities:length = 0) || ` +
+		`((@request.auth.role = "reviewer" || @request.auth.role = "community_admin") && ` +
+		`location != "" && (` +
+		`(@request.auth.role_communities:length > 0 && @request.auth.role_communities.location:each ?= location) || ` +
+		`(@request.auth.group_admin != "" && @request.auth.group_admin.location:each ?= location)))`
+
+	communitiesManageRule = `@request.auth.role = "superadmin" || ` +
+		`(@request.auth.role = "community_admin" && (@request.auth.group_admin = id || @request.auth.role_communities.id ?= id))`
+
+	communitiesUpdateRule = `@request.auth.role = "superadmin" || ` +
+		`(@request.auth.role = "community_admin" && (@request.auth.group_admin = id || @request.auth.role_communities.id ?= id) && ` +
+		`@request.body.type:isset = false && @request.body.location:isset = false && @request.body.archived:isset = false)`
+
+	superadminRule = `@request.auth.role = "superadmin"`
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Roles replace the single admin flag, which is kept in sync for the bot.
+		// role_communities scopes reviewers and community admins to communities
+		// beyond the one they are group admin of.
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+
+		usersCollection.Fields.Add(&core.SelectField{
+			Id:        "role",
+			Name:      "role",
+			Values:    userRoles,
+			MaxSelect: 1,
+		})
+		usersCollection.Fields.Add(&core.RelationField{
+			Id:           "role_communities",
+			Name:         "role_communities",
+			CollectionId: communitiesCollection.Id,
+			MaxSelect:    maxUserGroups,
+		})
+
+		if err := app.Save(usersCollection); err != nil {
+			return err
+		}
+
+		// Backfill from the admin flag and group admins
+		users, err := app.FindAllRecords("users")
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			role := "member"
+			switch {
+			case user.GetBool("admin"):
+				role = "superadmin"
+			case user.GetString("group_admin") != "":
+				role = "community_admin"
+			}
+			user.Set("role", role)
+			if err := app.SaveNoValidate(user); err != nil {
+				return err
+			}
+		}
+
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+		// Approvals create accounts, so requests are only updated through the web routes
+		requestsCollection.ListRule = types.Pointer(requestsReviewRule)
+		requestsCollection.ViewRule = types.Pointer(requestsReviewRule)
+		if err := app.Save(requestsCollection); err != nil {
+			return err
+		}
+
+		communitiesCollection.ListRule = types.Pointer(communitiesManageRule)
+		communitiesCollection.ViewRule = types.Pointer(communitiesManageRule)
+		communitiesCollection.UpdateRule = types.Pointer(communitiesUpdateRule)
+		communitiesCollection.CreateRule = types.Pointer(superadminRule)
+		communitiesCollection.DeleteRule = types.Pointer(superadminRule)
+
+		return app.Save(communitiesCollection)
+	}, func(app core.App) error {
+		// Revert - back to superuser-only rules and remove the fields
+		for _, name := range []string{"requests", "communities"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.ListRule = nil
+			collection.ViewRule = nil
+			collection.CreateRule = nil
+			collection.UpdateRule = nil
+			collection.DeleteRule = nil
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		usersCollection.Fields.RemoveByName("role")
+		usersCollection.Fields.RemoveByName("role_communities")
+
+		return app.Save(usersCollection)
+	}, sortedName("24_add_user_roles.go"))
+}
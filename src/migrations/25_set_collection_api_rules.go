@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// privilegedUserFields are set by approvals, the bot and the admin pages, never by
+// users on their own record
+var privilegedUserFields = []string{
+	"email", "verified", "status", "admin", "role", "role_communities",
+	"group_admin", "group_admin_since", "groups",
+	"telegram_id", "telegram_name", "telegram_token", "telegram_token_created",
+	"city", "location", "job_field", "interests",
+}
+
+// collectionRules are the API rules of one collection; nil leaves an action to
+// superusers and the server itself
+type collectionRules struct {
+	List, View, Create, Update, Delete *string
+}
+
+// apiRules returns the explicit API rules of every collection. The web pages and
+// the bot go through their own routes, so the REST API only exposes what each role
+// may already see there. Nobody creates records through it: accounts come from
+// approvals and requests from the registration form.
+func apiRules() map[string]collectionRules {
+	self := `id = @request.auth.id`
+	for _, field := range privilegedUserFields {
+		self += " && @request.body." + field + ":isset = false"
+	}
+
+	// Community admins see the members of the communities they manage
+	usersViewRule := `id = @request.auth.id || ` + superadminRule + ` || ` +
+		`(@request.auth.role = "community_admin" && (` +
+		`(@request.auth.group_admin != "" && groups.id ?= @request.auth.group_admin) || ` +
+		`(@request.auth.role_communities:length > 0 && groups.id ?= @request.auth.role_communities.id)))`
+
+	// Members see the communities they belong to
+	communitiesViewRule := `@request.auth.groups.id ?= id || ` + communitiesManageRule
+
+	// Invite links and rotation proposals are only visible to whom they concern
+	inviteLinksViewRule := `user = @request.auth.id || ` + superadminRule
+	rotationsViewRule := `outgoing = @request.auth.id || proposed = @request.auth.id || ` + superadminRule
+
+	return map[string]collectionRules{
+		"users": {
+			List:   types.Pointer(usersViewRule),
+			View:   types.Pointer(usersViewRule),
+			Update: types.Pointer(superadminRule + " || (" + self + ")"),
+			Delete: types.Pointer(superadminRule),
+		},
+		"communities": {
+			List:   types.Pointer(communitiesViewRule),
+			View:   types.Pointer(communitiesViewRule),
+			Create: types.Pointer(superadminRule),
+			Update: types.Pointer(communitiesUpdateRule),
+			Delete: types.Pointer(superadminRule),
+		},
+		"requests": {
+			List:   types.Pointer(requestsReviewRule),
+			View:   types.Pointer(requestsReviewRule),
+			Delete: types.Pointer(superadminRule),
+		},
+		"invite_links": {
+			List: types.Pointer(inviteLinksViewRule),
+			View: types.Pointer(inviteLinksViewRule),
+		},
+		"admin_rotations": {
+			List: types.Pointer(rotationsViewRule),
+			View: types.Pointer(rotationsViewRule),
+		},
+	}
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// The collections were created without rules, leaving users with the
+		// PocketBase defaults: open sign-up and updating any field of themselves
+		for name, rules := range apiRules() {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.ListRule = rules.List
+			collection.ViewRule = rules.View
+			collection.CreateRule = rules.Create
+			collection.UpdateRule = rules.Update
+			collection.DeleteRule = rules.Delete
+			if collection.IsAuth() {
+				// Superadmins may also change emails, verification and passwords
+				collection.ManageRule = types.Pointer(superadminRule)
+			}
+			if field := collection.Fields.GetByName("telegram_token"); field != nil {
+				// Anyone who can read a token can link their Telegram to its account
+				field.SetHidden(true)
+			}
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		// Revert - back to the rules migration 24 left behind
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		usersCollection.ListRule = nil
+		usersCollection.ViewRule = types.Pointer(`id = @request.auth.id`)
+		usersCollection.CreateRule = types.Pointer("")
+		usersCollection.UpdateRule = types.Pointer(`id = @request.auth.id`)
+		usersCollection.DeleteRule = types.Pointer(`id = @request.auth.id`)
+		usersCollection.ManageRule = nil
+		if field := usersCollection.Fields.GetByName("telegram_token"); field != nil {
+			field.SetHidden(false)
+		}
+		if err := app.Save(usersCollection); err != nil {
+			return err
+		}
+
+		communitiesCollection, err := app.FindCollectionByNameOrId("communities")
+		if err != nil {
+			return err
+		}
+		communitiesCollection.ListRule = types.Pointer(communitiesManageRule)
+		communitiesCollection.ViewRule = types.Pointer(communitiesManageRule)
+		if err := app.Save(communitiesCollection); err != nil {
+			return err
+		}
+
+		requestsCollection, err := app.FindCollectionByNameOrId("requests")
+		if err != nil {
+			return err
+		}
+		requestsCollection.DeleteRule = nil
+		if err := app.Save(requestsCollection); err != nil {
+			return err
+		}
+
+		for _, name := range []string{"invite_links", "admin_rotations"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.ListRule = nil
+			collection.ViewRule = nil
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, sortedName("25_set_collection_api_rules.go"))
+}
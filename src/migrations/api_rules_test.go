@@ -0,0 +1,380 @@
+package migrations_test
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "disciplo/src/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// Fixture record IDs. The member and the group admin belong to the north community,
+// the other member to the south one; each request comes from one of the regions.
+const (
+	northId       = "northcommunity"
+	southId       = "southcommunity"
+	memberId      = "memberuser00001"
+	otherMemberId = "memberuser00002"
+	groupAdminId  = "groupadminuser1"
+	superadminId  = "superadminuser1"
+	northReqId    = "northrequest001"
+	southReqId    = "southrequest001"
+)
+
+// principal is who calls the API: nobody, or one of the fixture users
+type principal struct {
+	name   string
+	userId string
+}
+
+var (
+	anonymous  = principal{"anonymous", ""}
+	member     = principal{"member", memberId}
+	groupAdmin = principal{"group admin", groupAdminId}
+	superadmin = principal{"admin", superadminId}
+)
+
+// fixture is the migrated app every scenario starts from a copy of, since running
+// the migrations for each one would take most of the test time
+var (
+	fixtureOnce sync.Once
+	fixture     *tests.TestApp
+	fixtureErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if fixture != nil {
+		fixture.Cleanup()
+	}
+	os.Exit(code)
+}
+
+// newRulesApp returns a copy of the fixture app
+func newRulesApp(t testing.TB) *tests.TestApp {
+	fixtureOnce.Do(func() {
+		fixture, fixtureErr = newFixtureApp()
+	})
+	if fixtureErr != nil {
+		t.Fatal(fixtureErr)
+	}
+
+	app, err := tests.NewTestApp(fixture.DataDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+// newFixtureApp returns a migrated test app with two regional communities, members
+// of each, a group admin of the north one, a superadmin and a request from each
+// region. Its database is closed so the data directory can be copied.
+func newFixtureApp() (*tests.TestApp, error) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		return nil, err
+	}
+	if err := app.RunAllMigrations(); err != nil {
+		app.Cleanup()
+		return nil, err
+	}
+
+	communities, err := app.FindCollectionByNameOrId("communities")
+	if err != nil {
+		app.Cleanup()
+		return nil, err
+	}
+	locations := communities.Fields.GetByName("location").(*core.SelectField).Values
+	north, south := locations[0], locations[1]
+
+	// save keeps the first error, so the records can be listed one after the other
+	var saveErr error
+	save := func(collection string, id string, fields map[string]any) {
+		if saveErr != nil {
+			return
+		}
+		c, err := app.FindCollectionByNameOrId(collection)
+		if err != nil {
+			saveErr = err
+			return
+		}
+		record := core.NewRecord(c)
+		record.Id = id
+		record.Load(fields)
+		if c.IsAuth() {
+			record.SetPassword("fixture-password")
+		}
+		saveErr = app.SaveNoValidate(record)
+	}
+
+	save("communities", northId, map[string]any{"name": "North", "type": "local", "location": []string{north}})
+	save("communities", southId, map[string]any{"name": "South", "type": "local", "location": []string{south}})
+
+	save("users", memberId, map[string]any{
+		"email": "member@example.com", "name": "Member", "role": "member",
+		"status": "accepted", "verified": true, "location": north, "groups": []string{northId},
+	})
+	save("users", otherMemberId, map[string]any{
+		"email": "other@example.com", "name": "Other", "role": "member",
+		"status": "accepted", "verified": true, "location": south, "groups": []string{southId},
+	})
+	save("users", groupAdminId, map[string]any{
+		"email": "groupadmin@example.com", "name": "Group Admin", "role": "community_admin",
+		"status": "accepted", "verified": true, "location": north, "groups": []string{northId}, "group_admin": northId,
+	})
+	save("users", superadminId, map[string]any{
+		"email": "admin@example.com", "name": "Admin", "role": "superadmin", "admin": true,
+		"status": "accepted", "verified": true,
+	})
+
+	save("requests", northReqId, map[string]any{"name": "North Applicant", "email": "north@example.com", "location": north, "status": "pending"})
+	save("requests", southReqId, map[string]any{"name": "South Applicant", "email": "south@example.com", "location": south, "status": "pending"})
+
+	if saveErr == nil {
+		saveErr = app.ResetBootstrapState()
+	}
+	if saveErr != nil {
+		app.Cleanup()
+		return nil, saveErr
+	}
+	return app, nil
+}
+
+// ruleCase is one request and the outcome expected for each principal
+type ruleCase struct {
+	name   string
+	method string
+	url    string
+	body   string
+
+	// status by principal, with the IDs expected in and absent from the response
+	status   map[principal]int
+	includes map[principal][]string
+	excludes map[principal][]string
+}
+
+func (rc ruleCase) scenarios() []tests.ApiScenario {
+	var scenarios []tests.ApiScenario
+	for _, p := range []principal{anonymous, member, groupAdmin, superadmin} {
+		headers := map[string]string{}
+		scenario := tests.ApiScenario{
+			Name:               rc.name + " as " + p.name,
+			Method:             rc.method,
+			URL:                rc.url,
+			Headers:            headers,
+			ExpectedStatus:     rc.status[p],
+			ExpectedContent:    quoted(rc.includes[p]),
+			NotExpectedContent: quoted(rc.excludes[p]),
+			TestAppFactory:     newRulesApp,
+			BeforeTestFunc: func(t testing.TB, app *tests.TestApp, e *core.ServeEvent) {
+				if p.userId == "" {
+					return
+				}
+				user, err := app.FindRecordById("users", p.userId)
+				if err != nil {
+					t.Fatal(err)
+				}
+				token, err := user.NewAuthToken()
+				if err != nil {
+					t.Fatal(err)
+				}
+				headers["Authorization"] = token
+			},
+		}
+		if rc.body != "" {
+			scenario.Body = strings.NewReader(rc.body)
+		}
+		if len(scenario.ExpectedContent) == 0 {
+			// ApiScenario requires some expected content; every JSON answer has braces
+			scenario.ExpectedContent = []string{"{"}
+		}
+		if scenario.ExpectedStatus == http.StatusNoContent {
+			scenario.ExpectedContent = nil
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios
+}
+
+// quoted turns record IDs into the JSON strings they appear as in responses
+func quoted(ids []string) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = `"` + id + `"`
+	}
+	return result
+}
+
+// all returns the same expectation for every principal
+func all[T any](value T) map[principal]T {
+	return map[principal]T{anonymous: value, member: value, groupAdmin: value, superadmin: value}
+}
+
+// except returns value for every principal but the overrides
+func except[T any](value T, overrides map[principal]T) map[principal]T {
+	result := all(value)
+	for p, v := range overrides {
+		result[p] = v
+	}
+	return result
+}
+
+func TestUsersApiRules(t *testing.T) {
+	cases := []ruleCase{
+		{
+			name: "list", method: http.MethodGet, url: "/api/collections/users/records?perPage=500",
+			status: all(http.StatusOK),
+			includes: map[principal][]string{
+				member:     {memberId},
+				groupAdmin: {groupAdminId, memberId},
+				superadmin: {superadminId, memberId, otherMemberId, groupAdminId},
+			},
+			excludes: map[principal][]string{
+				anonymous:  {memberId, otherMemberId, groupAdminId, superadminId},
+				member:     {otherMemberId, groupAdminId, superadminId},
+				groupAdmin: {otherMemberId, superadminId},
+			},
+		},
+		{
+			name: "view member of the north community", method: http.MethodGet, url: "/api/collections/users/records/" + memberId,
+			status:   except(http.StatusNotFound, map[principal]int{member: http.StatusOK, groupAdmin: http.StatusOK, superadmin: http.StatusOK}),
+			includes: except([]string(nil), map[principal][]string{member: {memberId}, groupAdmin: {memberId}, superadmin: {memberId}}),
+		},
+		{
+			name: "view member of another community", method: http.MethodGet, url: "/api/collections/users/records/" + otherMemberId,
+			status:   except(http.StatusNotFound, map[principal]int{superadmin: http.StatusOK}),
+			includes: map[principal][]string{superadmin: {otherMemberId}},
+		},
+		{
+			name: "create", method: http.MethodPost, url: "/api/collections/users/records",
+			body:   `{"email":"new@example.com","password":"new-password","passwordConfirm":"new-password"}`,
+			status: all(http.StatusForbidden),
+		},
+		{
+			name: "update own name", method: http.MethodPatch, url: "/api/collections/users/records/" + memberId,
+			body:     `{"name":"Renamed"}`,
+			status:   except(http.StatusNotFound, map[principal]int{member: http.StatusOK, superadmin: http.StatusOK}),
+			includes: map[principal][]string{member: {"Renamed"}, superadmin: {"Renamed"}},
+		},
+		{
+			name: "update own role", method: http.MethodPatch, url: "/api/collections/users/records/" + memberId,
+			body:     `{"role":"superadmin"}`,
+			status:   except(http.StatusNotFound, map[principal]int{superadmin: http.StatusOK}),
+			includes: map[principal][]string{superadmin: {"superadmin"}},
+		},
+		{
+			name: "delete", method: http.MethodDelete, url: "/api/collections/users/records/" + otherMemberId,
+			status: except(http.StatusNotFound, map[principal]int{superadmin: http.StatusNoContent}),
+		},
+	}
+
+	for _, rc := range cases {
+		for _, scenario := range rc.scenarios() {
+			scenario.Test(t)
+		}
+	}
+}
+
+func TestCommunitiesApiRules(t *testing.T) {
+	cases := []ruleCase{
+		{
+			name: "list", method: http.MethodGet, url: "/api/collections/communities/records",
+			status: all(http.StatusOK),
+			includes: map[principal][]string{
+				member:     {northId},
+				groupAdmin: {northId},
+				superadmin: {northId, southId},
+			},
+			excludes: map[principal][]string{
+				anonymous:  {northId, southId},
+				member:     {southId},
+				groupAdmin: {southId},
+			},
+		},
+		{
+			name: "view another community", method: http.MethodGet, url: "/api/collections/communities/records/" + southId,
+			status:   except(http.StatusNotFound, map[principal]int{superadmin: http.StatusOK}),
+			includes: map[principal][]string{superadmin: {southId}},
+		},
+		{
+			name: "create", method: http.MethodPost, url: "/api/collections/communities/records",
+			body:   `{"name":"New","type":"special"}`,
+			status: except(http.StatusBadRequest, map[principal]int{superadmin: http.StatusOK}),
+		},
+		{
+			name: "update name", method: http.MethodPatch, url: "/api/collections/communities/records/" + northId,
+			body:     `{"name":"Renamed"}`,
+			status:   except(http.StatusNotFound, map[principal]int{groupAdmin: http.StatusOK, superadmin: http.StatusOK}),
+			includes: map[principal][]string{groupAdmin: {"Renamed"}, superadmin: {"Renamed"}},
+		},
+		{
+			name: "update type", method: http.MethodPatch, url: "/api/collections/communities/records/" + northId,
+			body:     `{"type":"special"}`,
+			status:   except(http.StatusNotFound, map[principal]int{superadmin: http.StatusOK}),
+			includes: map[principal][]string{superadmin: {"special"}},
+		},
+		{
+			name: "delete", method: http.MethodDelete, url: "/api/collections/communities/records/" + southId,
+			status: except(http.StatusNotFound, map[principal]int{superadmin: http.StatusNoContent}),
+		},
+	}
+
+	for _, rc := range cases {
+		for _, scenario := range rc.scenarios() {
+			scenario.Test(t)
+		}
+	}
+}
+
+func TestRequestsApiRules(t *testing.T) {
+	cases := []ruleCase{
+		{
+			name: "list", method: http.MethodGet, url: "/api/collections/requests/records",
+			status: all(http.StatusOK),
+			includes: map[principal][]string{
+				groupAdmin: {northReqId},
+				superadmin: {northReqId, southReqId},
+			},
+			excludes: map[principal][]string{
+				anonymous:  {northReqId, southReqId},
+				member:     {northReqId, southReqId},
+				groupAdmin: {southReqId},
+			},
+		},
+		{
+			name: "view request from the community's region", method: http.MethodGet, url: "/api/collections/requests/records/" + northReqId,
+			status:   except(http.StatusNotFound, map[principal]int{groupAdmin: http.StatusOK, superadmin: http.StatusOK}),
+			includes: map[principal][]string{groupAdmin: {northReqId}, superadmin: {northReqId}},
+		},
+		{
+			name: "view request from another region", method: http.MethodGet, url: "/api/collections/requests/records/" + southReqId,
+			status:   except(http.StatusNotFound, map[principal]int{superadmin: http.StatusOK}),
+			includes: map[principal][]string{superadmin: {southReqId}},
+		},
+		{
+			name: "create", method: http.MethodPost, url: "/api/collections/requests/records",
+			body:   `{"name":"Applicant","email":"applicant@example.com","status":"pending"}`,
+			status: all(http.StatusForbidden),
+		},
+		{
+			name: "update", method: http.MethodPatch, url: "/api/collections/requests/records/" + northReqId,
+			body:   `{"status":"approved"}`,
+			status: all(http.StatusForbidden),
+		},
+		{
+			name: "delete", method: http.MethodDelete, url: "/api/collections/requests/records/" + southReqId,
+			status: except(http.StatusNotFound, map[principal]int{superadmin: http.StatusNoContent}),
+		},
+	}
+
+	for _, rc := range cases {
+		for _, scenario := range rc.scenarios() {
+			scenario.Test(t)
+		}
+	}
+}
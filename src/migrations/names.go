@@ -0,0 +1,9 @@
+package migrations
+
+// sortedName returns the name to register a migration numbered 10 or higher under.
+// PocketBase orders migrations by plain string comparison of their file names, so
+// "10_foo.go" would run before "2_create_collections.go". The "9z" prefix keeps
+// these after "9_..." while preserving their own numeric order (up to 99).
+func sortedName(file string) string {
+	return "9z" + file
+}
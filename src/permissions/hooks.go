@@ -0,0 +1,47 @@
+package permissions
+
+import "github.com/pocketbase/pocketbase/core"
+
+// RegisterHooks keeps roles and the legacy admin flag consistent, since the bot and
+// older code paths still read admin. Setting a role updates the flag; toggling the
+// flag, or gaining or losing a group admin community, updates the role.
+func RegisterHooks(app core.App) {
+	app.OnRecordCreate("users").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetString("role") == "" {
+			e.Record.Set("role", string(impliedRole(e.Record)))
+		}
+		e.Record.Set("admin", RoleOf(e.Record) == RoleSuperadmin)
+		return e.Next()
+	})
+
+	app.OnRecordUpdate("users").BindFunc(func(e *core.RecordEvent) error {
+		syncRole(e.Record)
+		return e.Next()
+	})
+}
+
+// syncRole updates a user's role or admin flag from whichever of them changed
+func syncRole(user *core.Record) {
+	original := user.Original()
+	role := RoleOf(user)
+
+	switch {
+	case role != RoleOf(original):
+		// The role was set explicitly
+	case user.GetBool("admin") != original.GetBool("admin"):
+		if user.GetBool("admin") {
+			role = RoleSuperadmin
+		} else if role == RoleSuperadmin {
+			role = impliedRole(user)
+		}
+	case user.GetString("group_admin") != original.GetString("group_admin"):
+		if role == RoleMember && user.GetString("group_admin") != "" {
+			role = RoleCommunityAdmin
+		} else if role == RoleCommunityAdmin && user.GetString("group_admin") == "" && len(user.GetStringSlice("role_communities")) == 0 {
+			role = RoleMember
+		}
+	}
+
+	user.Set("role", string(role))
+	user.Set("admin", role == RoleSuperadmin)
+}
@@ -0,0 +1,205 @@
+// Package permissions decides what a user may do from their role and the
+// communities their role is scoped to.
+//
+// Superadmins can do everything. Reviewers review membership requests, from every
+// region or only from the regions of their role communities. Community admins
+// review the requests of their communities' regions and manage those communities:
+// their details, chat and members, but not their type, regions or archived state.
+// A community admin's scope is their role communities plus the community they are
+// group admin of. Members have no admin permissions.
+//
+// The collection API rules set by migration 24 mirror these checks.
+package permissions
+
+import (
+	"slices"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Role is the value of the users role field
+type Role string
+
+const (
+	RoleSuperadmin     Role = "superadmin"
+	RoleReviewer       Role = "reviewer"
+	RoleCommunityAdmin Role = "community_admin"
+	RoleMember         Role = "member"
+)
+
+// Permission is something a role may be allowed to do
+type Permission string
+
+const (
+	// ReviewRequests lets a user list, approve and reject membership requests
+	ReviewRequests Permission = "review_requests"
+
+	// ManageCommunities lets a user edit communities and their members
+	ManageCommunities Permission = "manage_communities"
+
+	// ConfigureCommunities lets a user create and archive communities, set their
+	// type and regions, and run the local assignment
+	ConfigureCommunities Permission = "configure_communities"
+
+	// ViewAllMembers lets a user see every verified member in the directory
+	ViewAllMembers Permission = "view_all_members"
+)
+
+// rolePermissions lists what each role may do, within its scope
+var rolePermissions = map[Role][]Permission{
+	RoleSuperadmin:     {ReviewRequests, ManageCommunities, ConfigureCommunities, ViewAllMembers},
+	RoleReviewer:       {ReviewRequests},
+	RoleCommunityAdmin: {ReviewRequests, ManageCommunities},
+}
+
+// Can reports whether the role grants the permission
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
+
+// RoleOf returns the user's role. Users saved before roles existed get the role
+// their admin flag and group admin community imply.
+func RoleOf(user *core.Record) Role {
+	if role := Role(user.GetString("role")); role != "" {
+		return role
+	}
+	return impliedRole(user)
+}
+
+// impliedRole is the role matching the legacy admin flag and group_admin relation
+func impliedRole(user *core.Record) Role {
+	switch {
+	case user.GetBool("admin"):
+		return RoleSuperadmin
+	case user.GetString("group_admin") != "":
+		return RoleCommunityAdmin
+	}
+	return RoleMember
+}
+
+// IsSuperadmin reports whether the user has the superadmin role
+func IsSuperadmin(user *core.Record) bool {
+	return RoleOf(user) == RoleSuperadmin
+}
+
+// Access is what a signed-in user may do, resolved against their scope
+type Access struct {
+	User        *core.Record
+	Role        Role
+	Global      bool     // Not limited to the scoped communities
+	Communities []string // IDs of the scoped communities
+	Locations   []string // Regions covered by the scoped communities
+}
+
+// Resolve loads the user's role and scope
+func Resolve(app core.App, user *core.Record) (*Access, error) {
+	access := &Access{User: user, Role: RoleOf(user)}
+
+	scoped := user.GetStringSlice("role_communities")
+	switch access.Role {
+	case RoleSuperadmin:
+		access.Global = true
+		return access, nil
+	case RoleReviewer:
+		access.Global = len(scoped) == 0
+	case RoleMember:
+		return access, nil
+	}
+	if access.Global {
+		return access, nil
+	}
+
+	if groupAdmin := user.GetString("group_admin"); groupAdmin != "" && !slices.Contains(scoped, groupAdmin) {
+		scoped = append(scoped, groupAdmin)
+	}
+	access.Communities = scoped
+
+	communities, err := app.FindRecordsByIds("communities", scoped)
+	if err != nil {
+		return nil, err
+	}
+	for _, community := range communities {
+		for _, location := range community.GetStringSlice("location") {
+			if !slices.Contains(access.Locations, location) {
+				access.Locations = append(access.Locations, location)
+			}
+		}
+	}
+
+	return access, nil
+}
+
+// Can reports whether the user's role grants the permission
+func (a *Access) Can(p Permission) bool {
+	return a.Role.Can(p)
+}
+
+// CanReview reports whether the user may review the membership request
+func (a *Access) CanReview(request *core.Record) bool {
+	if !a.Can(ReviewRequests) {
+		return false
+	}
+	return a.Global || slices.Contains(a.Locations, request.GetString("location"))
+}
+
+// CanManage reports whether the user may edit the community and its members
+func (a *Access) CanManage(community *core.Record) bool {
+	if !a.Can(ManageCommunities) {
+		return false
+	}
+	return a.Global || slices.Contains(a.Communities, community.Id)
+}
+
+// CanAddMember reports whether the user may add the member to the communities they
+// manage: anyone when they manage every community, else members from their regions
+func (a *Access) CanAddMember(member *core.Record) bool {
+	if !a.Can(ManageCommunities) {
+		return false
+	}
+	return a.Global || slices.Contains(a.Locations, member.GetString("location"))
+}
+
+// RequestsExpr limits a requests query to the ones the user may review. It is nil
+// when the user reviews every request.
+func (a *Access) RequestsExpr() dbx.Expression {
+	if !a.Can(ReviewRequests) {
+		return dbx.NewExp("1=0")
+	}
+	if a.Global {
+		return nil
+	}
+	return dbx.In("location", toAny(a.Locations)...)
+}
+
+// CommunitiesExpr limits a communities query to the ones the user may manage. It
+// is nil when the user manages every community.
+func (a *Access) CommunitiesExpr() dbx.Expression {
+	if !a.Can(ManageCommunities) {
+		return dbx.NewExp("1=0")
+	}
+	if a.Global {
+		return nil
+	}
+	return dbx.In("id", toAny(a.Communities)...)
+}
+
+// MembersExpr limits a users query to the ones the user may add to the communities
+// they manage. It is nil when the user may add anyone.
+func (a *Access) MembersExpr() dbx.Expression {
+	if !a.Can(ManageCommunities) {
+		return dbx.NewExp("1=0")
+	}
+	if a.Global {
+		return nil
+	}
+	return dbx.In("location", toAny(a.Locations)...)
+}
+
+func toAny(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
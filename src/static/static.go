@@ -0,0 +1,72 @@
+// Package static embeds the web page and email templates so the binary runs from
+// any directory.
+package static
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultOverrideDir holds files that replace the embedded ones, laid out like
+// this directory, e.g. pb_public/templates/login.html
+const DefaultOverrideDir = "pb_public"
+
+//go:embed templates email_templates
+var embedded embed.FS
+
+// FS returns the static files. A file present in one of dirs, in order, takes
+// precedence over the embedded copy.
+func FS(dirs ...string) fs.FS {
+	layers := make([]fs.FS, 0, len(dirs)+1)
+	for _, dir := range dirs {
+		if dir != "" {
+			layers = append(layers, os.DirFS(dir))
+		}
+	}
+	return layeredFS(append(layers, embedded))
+}
+
+// Overrides lists the embedded files that dir replaces
+func Overrides(dir string) []string {
+	var names []string
+	fs.WalkDir(embedded, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil && !info.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names
+}
+
+// SourceDir returns the location of this directory in a source checkout, as seen
+// from the repository root or the build directory, or "" when there is none
+func SourceDir() string {
+	for _, dir := range []string{"src/static", "../src/static"} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// layeredFS opens a file from the first layer that has it
+type layeredFS []fs.FS
+
+func (l layeredFS) Open(name string) (fs.File, error) {
+	for _, layer := range l[:len(l)-1] {
+		file, err := layer.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return l[len(l)-1].Open(name)
+}
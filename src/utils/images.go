@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"disciplo/src/config"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// maxDecodePixels caps the area of pictures we decode. A small compressed file can
+// declare huge dimensions, and decoding allocates memory for every pixel up front.
+const maxDecodePixels = 40_000_000
+
+// ProcessedPicture is an uploaded picture after validation and resizing
+type ProcessedPicture struct {
+	Data      []byte
+	Extension string
+	MimeType  string
+}
+
+// ProcessProfilePicture sniffs, validates and (optionally) resizes an uploaded picture
+// according to the [registration.picture] settings. The image is always re-encoded,
+// which also strips any embedded metadata (EXIF, GPS, ...).
+func ProcessProfilePicture(data []byte, cfg config.PictureConfig) (*ProcessedPicture, error) {
+	if cfg.MaxSizeMB > 0 && len(data) > cfg.MaxSizeMB*1024*1024 {
+		return nil, fmt.Errorf("file size must be less than %dMB", cfg.MaxSizeMB)
+	}
+
+	// Sniff the real content type instead of trusting the filename or header
+	mimeType := http.DetectContentType(data)
+	var format string
+	switch mimeType {
+	case "image/jpeg":
+		format = "jpeg"
+	case "image/png":
+		format = "png"
+	default:
+		return nil, fmt.Errorf("unsupported image type %s", mimeType)
+	}
+
+	if !isFormatAllowed(format, cfg.AllowedFormats) {
+		return nil, fmt.Errorf("image format %s is not allowed (allowed: %s)", format, strings.Join(cfg.AllowedFormats, ", "))
+	}
+
+	// Read the dimensions from the header before decoding the pixels
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if imgConfig.Width <= 0 || imgConfig.Height <= 0 {
+		return nil, fmt.Errorf("image has invalid dimensions %dx%d", imgConfig.Width, imgConfig.Height)
+	}
+	if imgConfig.Width*imgConfig.Height > maxDecodePixels {
+		return nil, fmt.Errorf("image dimensions %dx%d are too large", imgConfig.Width, imgConfig.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if cfg.MaxDimensionPx > 0 {
+		bounds := img.Bounds()
+		if bounds.Dx() > cfg.MaxDimensionPx || bounds.Dy() > cfg.MaxDimensionPx {
+			if !cfg.AutoResize {
+				return nil, fmt.Errorf("image must be at most %dx%d pixels", cfg.MaxDimensionPx, cfg.MaxDimensionPx)
+			}
+			img = imaging.Fit(img, cfg.MaxDimensionPx, cfg.MaxDimensionPx, imaging.Lanczos)
+		}
+	}
+
+	var buf bytes.Buffer
+	picture := &ProcessedPicture{MimeType: mimeType}
+	if format == "png" {
+		err = png.Encode(&buf, img)
+		picture.Extension = "png"
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		picture.Extension = "jpg"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	picture.Data = buf.Bytes()
+	return picture, nil
+}
+
+// isFormatAllowed checks the format against the configured list (jpg and jpeg are equivalent)
+func isFormatAllowed(format string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimPrefix(a, "."))
+		if a == format || (format == "jpeg" && a == "jpg") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"disciplo/src/permissions"
+	"errors"
+	"fmt"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var (
+	errRequestNotFound  = errors.New("request not found")
+	errRequestProcessed = errors.New("request has already been processed")
+	errInvalidPassword  = errors.New("request has no valid stored password")
+)
+
+// memberProfileFields are the registration answers copied onto the user
+var memberProfileFields = []string{"city", "location", "job_field", "interests"}
+
+// approvalResult holds everything created by a successful approval
+type approvalResult struct {
+	Request       *core.Record
+	User          *core.Record
+	TelegramToken string
+}
+
+// approveRequest creates the user account for a pending request and marks the
+// request approved. User, Telegram token and request status are committed in a
+// single transaction, so a failure never leaves an orphan user or a pending
+// request whose account already exists. No email is sent here - callers must
+// notify the applicant only after this returns successfully. Requests outside the
+// reviewer's scope are reported as not found.
+func approveRequest(app core.App, requestId string, reviewer *permissions.Access) (*approvalResult, error) {
+	result := &approvalResult{}
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		// Re-read the request inside the transaction so concurrent approvals can't both pass
+		request, err := txApp.FindRecordById("requests", requestId)
+		if err != nil || !reviewer.CanReview(request) {
+			return errRequestNotFound
+		}
+
+		if request.GetString("status") != "pending" {
+			return errRequestProcessed
+		}
+
+		authCollection, err := txApp.FindCollectionByNameOrId("_pb_users_auth_")
+		if err != nil {
+			return fmt.Errorf("failed to find auth collection: %w", err)
+		}
+
+		// The applicant's password was bcrypt-hashed at registration, which is
+		// the same format PocketBase stores, so the hash is carried over as-is
+		passwordHash := request.GetString("password")
+		if !isBcryptHash(passwordHash) {
+			return errInvalidPassword
+		}
+
+		token, err := gonanoid.New(21)
+		if err != nil {
+			return fmt.Errorf("failed to generate telegram token: %w", err)
+		}
+
+		newUser := core.NewRecord(authCollection)
+
+		// Set basic auth data from request
+		newUser.Set("name", request.GetString("name"))
+		newUser.Set("email", request.GetString("email"))
+		newUser.SetRaw("password", &core.PasswordFieldValue{Hash: passwordHash}) // Raw set skips re-hashing
+		newUser.Set("emailVisibility", false)
+		// User starts with verified=false until Telegram is linked
+
+		// Set additional fields for our platform
+		newUser.Set("admin", false)
+		newUser.Set("status", "accepted")
+		newUser.Set("telegram_id", "")
+		newUser.Set("telegram_name", "")
+		newUser.Set("telegram_token", token)
+		newUser.Set("telegram_token_created", types.NowDateTime())
+		newUser.Set("groups", "")
+		newUser.Set("group_admin", "")
+		newUser.Set("group_admin_since", "")
+
+		// Carry the registration profile over for communities and the member directory
+		for _, field := range memberProfileFields {
+			newUser.Set(field, request.Get(field))
+		}
+
+		// Carry the registration picture over as the user's avatar
+		avatar, err := copyRecordFile(txApp, request, "profile_picture")
+		if err != nil {
+			fmt.Printf("Warning: Failed to copy profile picture for request %s: %v\n", requestId, err)
+		} else if avatar != nil {
+			newUser.Set("avatar", avatar)
+		}
+
+		if err := txApp.Save(newUser); err != nil {
+			return fmt.Errorf("failed to create user account: %w", err)
+		}
+
+		// Update request with approval details
+		request.Set("status", "approved")
+		request.Set("approved_by", reviewer.User.Id)
+		request.Set("approved_at", types.NowDateTime())
+		request.Set("created_user_id", newUser.Id)
+		request.Set("password", "") // Credential now lives only in the users record
+
+		if err := txApp.Save(request); err != nil {
+			return fmt.Errorf("failed to update request: %w", err)
+		}
+
+		result.Request = request
+		result.User = newUser
+		result.TelegramToken = token
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
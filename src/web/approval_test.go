@@ -0,0 +1,116 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"disciplo/src/permissions"
+
+	_ "disciplo/src/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestApp returns a test app with every migration applied
+func newTestApp(t testing.TB) *tests.TestApp {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RunAllMigrations(); err != nil {
+		app.Cleanup()
+		t.Fatal(err)
+	}
+	return app
+}
+
+// approvedApplicantApp registers a request with the given password the way
+// /api/register does and approves it
+func approvedApplicantApp(t testing.TB, email, password string) *tests.TestApp {
+	app := newTestApp(t)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests, err := app.FindCollectionByNameOrId("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := core.NewRecord(requests)
+	request.Set("name", "Applicant")
+	request.Set("email", email)
+	request.Set("password", string(hash))
+	request.Set("date_of_birth", "1990-01-01 00:00:00.000Z")
+	request.Set("city", "Milano")
+	// Pick the first configured option of every select
+	for _, name := range []string{"location", "job_field", "interests"} {
+		request.Set(name, requests.Fields.GetByName(name).(*core.SelectField).Values[0])
+	}
+	request.Set("why_join", "To meet people")
+	request.Set("status", "pending")
+	if err := app.SaveNoValidate(request); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The test data enables MFA on users, which a fresh install doesn't
+	users.MFA.Enabled = false
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+	admin := core.NewRecord(users)
+	admin.Set("email", "reviewer@example.com")
+	admin.Set("role", string(permissions.RoleSuperadmin))
+	admin.SetRandomPassword()
+	if err := app.SaveNoValidate(admin); err != nil {
+		t.Fatal(err)
+	}
+
+	reviewer, err := permissions.Resolve(app, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := approveRequest(app, request.Id, reviewer); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+func TestApprovedApplicantAuthenticatesWithRegisteredPassword(t *testing.T) {
+	const email, password = "applicant@example.com", "registered-secret"
+
+	scenarios := []tests.ApiScenario{
+		{
+			Name:            "registered password",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-password",
+			Body:            strings.NewReader(`{"identity":"` + email + `","password":"` + password + `"}`),
+			ExpectedStatus:  200,
+			ExpectedContent: []string{`"token":`, `"email":"` + email + `"`},
+		},
+		{
+			Name:            "wrong password",
+			Method:          http.MethodPost,
+			URL:             "/api/collections/users/auth-with-password",
+			Body:            strings.NewReader(`{"identity":"` + email + `","password":"not-the-password"}`),
+			ExpectedStatus:  400,
+			ExpectedContent: []string{`"data":{}`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		scenario.TestAppFactory = func(t testing.TB) *tests.TestApp {
+			return approvedApplicantApp(t, email, password)
+		}
+		scenario.Test(t)
+	}
+}
@@ -0,0 +1,188 @@
+package web
+
+import (
+	"disciplo/src/config"
+	"disciplo/src/email"
+	"disciplo/src/permissions"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// maxBulkRequests caps how many requests a single bulk call may process
+	maxBulkRequests = 200
+
+	// bulkItemTimeout is the write deadline granted for each processed request
+	bulkItemTimeout = time.Minute
+)
+
+// bulkInput is the body accepted by /api/admin/requests/bulk
+type bulkInput struct {
+	Action string   `json:"action"` // "approve" or "reject"
+	IDs    []string `json:"ids"`
+	rejectionInput
+}
+
+// bulkItemResult is streamed once per processed request
+type bulkItemResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+	Emailed bool   `json:"emailed"`
+}
+
+// bulkSummary is streamed as the last line of a bulk response
+type bulkSummary struct {
+	Done      bool `json:"done"`
+	Total     int  `json:"total"`
+	Succeeded int  `json:"succeeded"`
+	Failed    int  `json:"failed"`
+}
+
+// handleBulkRequests approves or rejects a list of requests, streaming one NDJSON line per
+// item as it completes so long runs of welcome emails don't hit the HTTP timeout
+func handleBulkRequests(c *core.RequestEvent, cfg *config.Config, disciploConfig *config.DisciploConfig, reviewer *permissions.Access) error {
+	if !disciploConfig.Admin.BulkOperations {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "Bulk operations are disabled"})
+	}
+
+	var input bulkInput
+	if err := c.BindBody(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request data"})
+	}
+
+	if input.Action != "approve" && input.Action != "reject" {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Action must be 'approve' or 'reject'"})
+	}
+
+	// Approvals create accounts, so they stay one by one when individual review is required
+	if input.Action == "approve" && disciploConfig.Admin.IndividualReview {
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"error": "Requests must be approved individually"})
+	}
+
+	ids := uniqueIDs(input.IDs)
+	if len(ids) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "No request IDs given"})
+	}
+	if len(ids) > maxBulkRequests {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error": fmt.Sprintf("At most %d requests can be processed at once", maxBulkRequests),
+		})
+	}
+
+	reason := ""
+	if input.Action == "reject" {
+		var err error
+		reason, err = resolveRejectionReason(disciploConfig.Admin, input.rejectionInput)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "A rejection reason is required"})
+		}
+	}
+
+	c.Response.Header().Set("Content-Type", "application/x-ndjson")
+	c.Response.Header().Set("Cache-Control", "no-store")
+	c.Response.Header().Set("X-Accel-Buffering", "no") // Don't let the reverse proxy buffer progress
+	c.Response.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(c.Response)
+	encoder := json.NewEncoder(c.Response)
+	summary := bulkSummary{Total: len(ids)}
+
+	for _, id := range ids {
+		// Each item gets a fresh write deadline, so only a single stuck item can time out
+		controller.SetWriteDeadline(time.Now().Add(bulkItemTimeout))
+
+		var result bulkItemResult
+		if input.Action == "approve" {
+			result = bulkApprove(c.App, cfg, disciploConfig, id, reviewer)
+		} else {
+			result = bulkReject(c.App, cfg, disciploConfig, id, reviewer, reason, input.Notify)
+		}
+
+		if result.Success {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+
+		if err := encoder.Encode(result); err != nil {
+			return nil // Client went away; processed items are already committed
+		}
+		controller.Flush()
+	}
+
+	summary.Done = true
+	encoder.Encode(summary)
+	controller.Flush()
+
+	fmt.Printf("Bulk %s by %s: %d succeeded, %d failed\n", input.Action, reviewer.User.Id, summary.Succeeded, summary.Failed)
+
+	return nil
+}
+
+func bulkApprove(app core.App, cfg *config.Config, disciploConfig *config.DisciploConfig, id string, reviewer *permissions.Access) bulkItemResult {
+	result, err := approveRequest(app, id, reviewer)
+	if err != nil {
+		return bulkItemResult{ID: id, Error: bulkErrorMessage(err)}
+	}
+
+	emailData := email.NewTemplateData(cfg, disciploConfig, result.Request)
+	emailData.SetTelegramToken(result.TelegramToken)
+	emailErr := email.SendApprovalWelcome(app, disciploConfig, emailData)
+	if emailErr != nil {
+		fmt.Printf("Warning: Failed to send welcome email for request %s: %v\n", id, emailErr)
+	}
+
+	return bulkItemResult{ID: id, Success: true, UserID: result.User.Id, Emailed: emailErr == nil}
+}
+
+func bulkReject(app core.App, cfg *config.Config, disciploConfig *config.DisciploConfig, id string, reviewer *permissions.Access, reason string, notify bool) bulkItemResult {
+	request, err := rejectRequest(app, id, reviewer, reason)
+	if err != nil {
+		return bulkItemResult{ID: id, Error: bulkErrorMessage(err)}
+	}
+
+	emailed := false
+	if notify && disciploConfig.Admin.RejectionEmail {
+		emailData := email.NewTemplateData(cfg, disciploConfig, request)
+		if err := email.SendRejection(app, disciploConfig, emailData); err != nil {
+			fmt.Printf("Warning: Failed to send rejection email for request %s: %v\n", id, err)
+		} else {
+			emailed = true
+		}
+	}
+
+	return bulkItemResult{ID: id, Success: true, Emailed: emailed}
+}
+
+// bulkErrorMessage turns approval/rejection errors into per-item messages
+func bulkErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errRequestNotFound):
+		return "Request not found"
+	case errors.Is(err, errRequestProcessed):
+		return "Request has already been processed"
+	default:
+		return err.Error()
+	}
+}
+
+// uniqueIDs drops empty and duplicate IDs, keeping the original order
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	var result []string
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,320 @@
+package web
+
+import (
+	"disciplo/src/bot"
+	"disciplo/src/config"
+	"disciplo/src/permissions"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// communityTypes are the values of the communities type select
+var communityTypes = []string{"default", "local", "special"}
+
+// communityInput is the body accepted when creating or editing a community
+type communityInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Locations   []string `json:"locations"`   // Regions covered, only kept for local communities
+	TelegramID  string   `json:"telegram_id"` // Chat to link, empty to unlink
+}
+
+// communitySummary is a community as listed on the admin pages
+type communitySummary struct {
+	Id               string
+	Name             string
+	Description      string
+	Type             string
+	Locations        []string
+	TelegramID       string
+	TelegramUsername string
+	TelegramType     string
+	ChatMembers      int // Last member count fetched from Telegram
+	Members          int // Users with the community in their groups
+	Archived         bool
+}
+
+// communityMember is a user as listed on a community page
+type communityMember struct {
+	Id           string
+	Name         string
+	Email        string
+	TelegramName string
+	Verified     bool
+}
+
+func newCommunitySummary(app core.App, community *core.Record) communitySummary {
+	members, _ := app.CountRecords("users", inGroupsExpr(community.Id))
+	return communitySummary{
+		Id:               community.Id,
+		Name:             community.GetString("name"),
+		Description:      community.GetString("description"),
+		Type:             community.GetString("type"),
+		Locations:        community.GetStringSlice("location"),
+		TelegramID:       community.GetString("telegram_id"),
+		TelegramUsername: community.GetString("telegram_username"),
+		TelegramType:     community.GetString("telegram_type"),
+		ChatMembers:      community.GetInt("member_count"),
+		Members:          int(members),
+		Archived:         community.GetBool("archived"),
+	}
+}
+
+func newCommunityMember(user *core.Record) communityMember {
+	return communityMember{
+		Id:           user.Id,
+		Name:         user.GetString("name"),
+		Email:        user.GetString("email"),
+		TelegramName: user.GetString("telegram_name"),
+		Verified:     user.GetBool("verified"),
+	}
+}
+
+// inGroupsExpr matches users whose groups include the community
+func inGroupsExpr(communityId string) dbx.Expression {
+	return dbx.NewExp(
+		"json_valid([[groups]]) AND EXISTS (SELECT 1 FROM json_each([[groups]]) WHERE json_each.value = {:community})",
+		dbx.Params{"community": communityId},
+	)
+}
+
+// findCommunities lists the active or the archived communities by name, limited
+// to scope unless it is nil
+func findCommunities(app core.App, archived bool, scope dbx.Expression) ([]communitySummary, error) {
+	records := []*core.Record{}
+	query := app.RecordQuery("communities").AndWhere(dbx.HashExp{"archived": archived})
+	if scope != nil {
+		query.AndWhere(scope)
+	}
+	if err := query.OrderBy("name ASC").All(&records); err != nil {
+		return nil, err
+	}
+
+	communities := []communitySummary{}
+	for _, record := range records {
+		communities = append(communities, newCommunitySummary(app, record))
+	}
+	return communities, nil
+}
+
+// findCommunityMembers returns the community's members and the accepted users who
+// could be added to it, limited to scope unless it is nil
+func findCommunityMembers(app core.App, communityId string, scope dbx.Expression) (members, candidates []communityMember, err error) {
+	users := []*core.Record{}
+	query := app.RecordQuery("users").AndWhere(dbx.HashExp{"status": "accepted"})
+	if scope != nil {
+		query.AndWhere(dbx.Or(inGroupsExpr(communityId), scope))
+	}
+	if err := query.OrderBy("name ASC").All(&users); err != nil {
+		return nil, nil, err
+	}
+
+	members, candidates = []communityMember{}, []communityMember{}
+	for _, user := range users {
+		if containsString(user.GetStringSlice("groups"), communityId) {
+			members = append(members, newCommunityMember(user))
+		} else {
+			candidates = append(candidates, newCommunityMember(user))
+		}
+	}
+	return members, candidates, nil
+}
+
+// handleSaveCommunity creates a community, or updates it when community is not new,
+// and links or unlinks its Telegram chat through the bot. Nothing is saved when the
+// new chat can't be linked. Unless configure is set, the community keeps its type
+// and regions.
+func handleSaveCommunity(c *core.RequestEvent, disciploConfig *config.DisciploConfig, community *core.Record, configure bool) error {
+	var input communityInput
+	if err := c.BindBody(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request data"})
+	}
+	if !configure {
+		input.Type = community.GetString("type")
+		input.Locations = community.GetStringSlice("location")
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	input.Description = strings.TrimSpace(input.Description)
+	input.TelegramID = strings.TrimSpace(input.TelegramID)
+
+	if input.Name == "" || len(input.Name) > 100 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Name is required (max 100 characters)"})
+	}
+	if !containsString(communityTypes, input.Type) {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Type must be default, local or special"})
+	}
+	if input.Type != "local" {
+		input.Locations = []string{}
+	} else {
+		if len(input.Locations) == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Local communities need at least one of the registration locations"})
+		}
+		for _, location := range input.Locations {
+			if !containsString(disciploConfig.Registration.Locations.Options, location) {
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Unknown location: " + location})
+			}
+		}
+	}
+
+	var chatID int64
+	if input.TelegramID != "" {
+		id, err := strconv.ParseInt(input.TelegramID, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Telegram chat ID must be a number, e.g. -1001234567890"})
+		}
+		chatID = id
+	}
+	relink := input.TelegramID != community.GetString("telegram_id")
+
+	community.Set("name", input.Name)
+	community.Set("description", input.Description)
+	community.Set("type", input.Type)
+	community.Set("location", input.Locations)
+
+	warnings := []string{}
+	if relink && chatID != 0 {
+		// The bot saves the community once it can reach the new chat, so a failed
+		// relink leaves the old chat linked and nothing else changed either
+		b := bot.Running()
+		if b == nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{"error": "The Telegram bot is not running, so the chat can't be linked"})
+		}
+		community.Set("member_count", 0)
+		missing, err := b.LinkChat(community, chatID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "The chat was not linked: " + err.Error()})
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, "Make the bot an administrator of the chat with these rights: "+strings.Join(missing, ", "))
+		}
+	} else {
+		if relink {
+			community.Set("telegram_id", "")
+			community.Set("telegram_username", "")
+			community.Set("telegram_type", "")
+			community.Set("member_count", 0)
+		}
+		if err := c.App.Save(community); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Failed to save community: " + err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"id":       community.Id,
+		"warnings": warnings,
+	})
+}
+
+// handleArchiveCommunity archives or restores a community
+func handleArchiveCommunity(c *core.RequestEvent, community *core.Record) error {
+	var input struct {
+		Archived bool `json:"archived"`
+	}
+	if err := c.BindBody(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request data"})
+	}
+
+	community.Set("archived", input.Archived)
+	if err := c.App.Save(community); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to update community"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// handleAddCommunityMember adds an accepted user to a community and, when they are
+// on Telegram, DMs them an invite link to its chat
+func handleAddCommunityMember(c *core.RequestEvent, community *core.Record) error {
+	if community.GetBool("archived") {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Archived communities can't get new members"})
+	}
+
+	var input struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.BindBody(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request data"})
+	}
+
+	access, err := permissions.Resolve(c.App, getAuthenticatedUser(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to load permissions"})
+	}
+
+	// Community admins only add members from their own regions
+	user, err := c.App.FindRecordById("users", input.UserID)
+	if err != nil || user.GetString("status") != "accepted" || !access.CanAddMember(user) {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Accepted user not found"})
+	}
+
+	groups := user.GetStringSlice("groups")
+	if containsString(groups, community.Id) {
+		return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
+	}
+	user.Set("groups", append(groups, community.Id))
+	if err := c.App.Save(user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to add member"})
+	}
+
+	invited := false
+	if b := bot.Running(); b != nil && user.GetBool("verified") && community.GetString("telegram_id") != "" {
+		invited = true
+		go func() {
+			if err := b.InviteMember(user, community); err != nil {
+				fmt.Printf("Warning: Failed to invite %s to %s: %v\n", user.GetString("email"), community.GetString("name"), err)
+			}
+		}()
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "invited": invited})
+}
+
+// handleRemoveCommunityMember removes a user from a community and kicks them from
+// its chat
+func handleRemoveCommunityMember(c *core.RequestEvent, community *core.Record) error {
+	user, err := c.App.FindRecordById("users", c.Request.PathValue("userId"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "User not found"})
+	}
+
+	groups := user.GetStringSlice("groups")
+	remaining := make([]string, 0, len(groups))
+	for _, id := range groups {
+		if id != community.Id {
+			remaining = append(remaining, id)
+		}
+	}
+	user.Set("groups", remaining)
+	if err := c.App.Save(user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to remove member"})
+	}
+
+	if b := bot.Running(); b != nil && community.GetString("telegram_id") != "" {
+		go b.RemoveMember(user, community)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// handleCommunityMemberCount fetches the live member count of a community's chat
+func handleCommunityMemberCount(c *core.RequestEvent, community *core.Record) error {
+	b := bot.Running()
+	if b == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{"error": "The Telegram bot is not running"})
+	}
+
+	count, err := b.RefreshMemberCount(community)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]interface{}{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "count": count})
+}
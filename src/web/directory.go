@@ -0,0 +1,168 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// directoryField is a member card field a member can choose to show to other members
+type directoryField struct {
+	Name  string
+	Label string
+}
+
+// directoryFields are the values of the users directory_fields select, in card order
+var directoryFields = []directoryField{
+	{"email", "Email"},
+	{"city", "City"},
+	{"location", "Location"},
+	{"job_field", "Job field"},
+	{"interests", "Interests"},
+	{"telegram", "Telegram"},
+}
+
+// directoryQuery holds the member directory filters
+type directoryQuery struct {
+	Location string
+	JobField string
+	Interest string
+	Search   string // Free text over the name, and the email in the admin view
+}
+
+// memberCard is a member as shown in the directory. Fields the member keeps hidden
+// are left empty in the member view.
+type memberCard struct {
+	Id           string
+	Name         string
+	Email        string
+	City         string
+	Location     string
+	JobField     string
+	Interests    []string
+	TelegramName string
+	AvatarURL    string
+	Listed       bool // Whether the member opted in to the member view
+}
+
+// Initial is shown in place of a missing avatar
+func (c memberCard) Initial() string {
+	for _, r := range c.Name {
+		return strings.ToUpper(string(r))
+	}
+	return "?"
+}
+
+func parseDirectoryQuery(r *http.Request) directoryQuery {
+	values := r.URL.Query()
+	return directoryQuery{
+		Location: values.Get("location"),
+		JobField: values.Get("job_field"),
+		Interest: values.Get("interest"),
+		Search:   strings.TrimSpace(values.Get("q")),
+	}
+}
+
+// matches reports whether a card passes the filters. Filtering runs on the card
+// rather than in SQL so that hidden fields can't be probed through the filters.
+func (q directoryQuery) matches(card memberCard, adminView bool) bool {
+	if q.Location != "" && card.Location != q.Location {
+		return false
+	}
+	if q.JobField != "" && card.JobField != q.JobField {
+		return false
+	}
+	if q.Interest != "" && !containsString(card.Interests, q.Interest) {
+		return false
+	}
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(card.Name), search) &&
+			!(adminView && strings.Contains(strings.ToLower(card.Email), search)) {
+			return false
+		}
+	}
+	return true
+}
+
+// findDirectory returns the cards of accepted, verified members. The admin view
+// lists everyone with every field; the member view lists only members who opted in,
+// with the fields they chose to show.
+func findDirectory(app core.App, q directoryQuery, adminView bool) ([]memberCard, error) {
+	exprs := []dbx.Expression{dbx.HashExp{"status": "accepted", "verified": true}}
+	if !adminView {
+		exprs = append(exprs, dbx.HashExp{"directory_visible": true})
+	}
+
+	records := []*core.Record{}
+	if err := app.RecordQuery("users").AndWhere(dbx.And(exprs...)).OrderBy("name ASC").All(&records); err != nil {
+		return nil, err
+	}
+
+	cards := []memberCard{}
+	for _, record := range records {
+		card := newMemberCard(record, adminView)
+		if q.matches(card, adminView) {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+// newMemberCard builds a member's card, with every field when shown to admins
+func newMemberCard(user *core.Record, adminView bool) memberCard {
+	shown := user.GetStringSlice("directory_fields")
+	visible := func(field string) bool {
+		return adminView || containsString(shown, field)
+	}
+
+	card := memberCard{
+		Id:     user.Id,
+		Name:   user.GetString("name"),
+		Listed: user.GetBool("directory_visible"),
+	}
+	if avatar := user.GetString("avatar"); avatar != "" {
+		card.AvatarURL = "/api/files/" + user.Collection().Id + "/" + user.Id + "/" + avatar + "?thumb=150x150"
+	}
+	if visible("email") {
+		card.Email = user.GetString("email")
+	}
+	if visible("city") {
+		card.City = user.GetString("city")
+	}
+	if visible("location") {
+		card.Location = user.GetString("location")
+	}
+	if visible("job_field") {
+		card.JobField = user.GetString("job_field")
+	}
+	if visible("interests") {
+		card.Interests = user.GetStringSlice("interests")
+	}
+	if visible("telegram") {
+		card.TelegramName = user.GetString("telegram_name")
+	}
+	return card
+}
+
+// validDirectoryFields drops unknown names from a member's chosen fields
+func validDirectoryFields(fields []string) []string {
+	valid := []string{}
+	for _, field := range directoryFields {
+		if containsString(fields, field.Name) {
+			valid = append(valid, field.Name)
+		}
+	}
+	return valid
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"disciplo/src/bot"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// localAssignmentRow is a pending local community change as previewed by admins
+type localAssignmentRow struct {
+	Id       string
+	Name     string
+	Email    string
+	Location string
+	Add      []string
+	Remove   []string
+}
+
+// registerLocalAssignmentHooks keeps members in the local communities covering
+// their location: new accepted users, including those created by an approval, get
+// theirs on creation, and members whose location changes are moved on save. Moves
+// are mirrored on Telegram once the save succeeds.
+func registerLocalAssignmentHooks(app core.App, fallback string) {
+	var pendingSyncs sync.Map
+
+	app.OnRecordCreate("users").BindFunc(func(e *core.RecordEvent) error {
+		if err := assignLocalCommunities(e.App, e.Record, fallback); err != nil {
+			fmt.Printf("Warning: Failed to assign local communities to %s: %v\n", e.Record.GetString("email"), err)
+		}
+		return e.Next()
+	})
+
+	app.OnRecordUpdate("users").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		if original.GetString("location") == e.Record.GetString("location") &&
+			original.GetString("status") == e.Record.GetString("status") {
+			return e.Next()
+		}
+
+		plan, err := bot.PlanLocalAssignment(e.App, e.Record, fallback)
+		if err != nil {
+			fmt.Printf("Warning: Failed to assign local communities to %s: %v\n", e.Record.GetString("email"), err)
+			return e.Next()
+		}
+		if plan.Empty() {
+			return e.Next()
+		}
+		plan.Apply()
+		pendingSyncs.Store(e.Record.Id, plan)
+
+		return e.Next()
+	})
+
+	app.OnRecordAfterUpdateSuccess("users").BindFunc(func(e *core.RecordEvent) error {
+		if plan, ok := pendingSyncs.LoadAndDelete(e.Record.Id); ok {
+			if b := bot.Running(); b != nil {
+				go b.SyncLocalAssignment(plan.(*bot.LocalAssignment))
+			}
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAfterUpdateError("users").BindFunc(func(e *core.RecordErrorEvent) error {
+		pendingSyncs.Delete(e.Record.Id)
+		return e.Next()
+	})
+}
+
+// assignLocalCommunities applies a user's local assignment without saving them
+func assignLocalCommunities(app core.App, user *core.Record, fallback string) error {
+	plan, err := bot.PlanLocalAssignment(app, user, fallback)
+	if err != nil {
+		return err
+	}
+	plan.Apply()
+	return nil
+}
+
+// planLocalAssignments returns the pending local community changes of every
+// accepted user, skipping users already where they belong
+func planLocalAssignments(app core.App, fallback string) ([]*bot.LocalAssignment, error) {
+	users := []*core.Record{}
+	if err := app.RecordQuery("users").AndWhere(dbx.HashExp{"status": "accepted"}).OrderBy("name ASC").All(&users); err != nil {
+		return nil, err
+	}
+
+	plans := []*bot.LocalAssignment{}
+	for _, user := range users {
+		plan, err := bot.PlanLocalAssignment(app, user, fallback)
+		if err != nil {
+			return nil, err
+		}
+		if !plan.Empty() {
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+func newLocalAssignmentRow(plan *bot.LocalAssignment) localAssignmentRow {
+	row := localAssignmentRow{
+		Id:       plan.User.Id,
+		Name:     plan.User.GetString("name"),
+		Email:    plan.User.GetString("email"),
+		Location: plan.User.GetString("location"),
+	}
+	for _, community := range plan.Add {
+		row.Add = append(row.Add, community.GetString("name"))
+	}
+	for _, community := range plan.Remove {
+		row.Remove = append(row.Remove, community.GetString("name"))
+	}
+	return row
+}
+
+// handleApplyLocalAssignments re-runs the local assignment for every accepted user,
+// typically after a local community was added, and invites or removes members on
+// Telegram in the background
+func handleApplyLocalAssignments(c *core.RequestEvent, fallback string) error {
+	plans, err := planLocalAssignments(c.App, fallback)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to plan local assignments: " + err.Error()})
+	}
+
+	applied := []*bot.LocalAssignment{}
+	failed := 0
+	for _, plan := range plans {
+		plan.Apply()
+		if err := c.App.Save(plan.User); err != nil {
+			fmt.Printf("Warning: Failed to save local assignment for %s: %v\n", plan.User.GetString("email"), err)
+			failed++
+			continue
+		}
+		applied = append(applied, plan)
+	}
+
+	if b := bot.Running(); b != nil && len(applied) > 0 {
+		go func() {
+			for _, plan := range applied {
+				b.SyncLocalAssignment(plan)
+			}
+		}()
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"applied": len(applied),
+		"failed":  failed,
+	})
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"disciplo/src/config"
+	"disciplo/src/utils"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// readProfilePicture validates and resizes an uploaded profile picture
+func readProfilePicture(fileHeader *multipart.FileHeader, pictureConfig config.PictureConfig) (*filesystem.File, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	picture, err := utils.ProcessProfilePicture(data, pictureConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return filesystem.NewFileFromBytes(picture.Data, "profile."+picture.Extension)
+}
+
+// copyRecordFile returns a copy of a record's stored file, ready to be set on another record
+func copyRecordFile(app core.App, record *core.Record, fieldName string) (*filesystem.File, error) {
+	filename := record.GetString(fieldName)
+	if filename == "" {
+		return nil, nil
+	}
+
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+	defer fsys.Close()
+
+	reader, err := fsys.GetFile(record.BaseFilesPath() + "/" + filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return filesystem.NewFileFromBytes(data, filename)
+}
+
+// serveRecordFile streams a record's stored file (or one of its thumbs) to the response
+func serveRecordFile(c *core.RequestEvent, record *core.Record, fieldName string, thumb string) error {
+	filename := record.GetString(fieldName)
+	if filename == "" {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	fsys, err := c.App.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	fileKey := record.BaseFilesPath() + "/" + filename
+	if thumb != "" {
+		thumbKey := record.BaseFilesPath() + "/thumbs_" + filename + "/" + thumb + "_" + filename
+		if exists, _ := fsys.Exists(thumbKey); !exists {
+			if err := fsys.CreateThumb(fileKey, thumbKey, thumb); err == nil {
+				fileKey = thumbKey
+			}
+		} else {
+			fileKey = thumbKey
+		}
+	}
+
+	return fsys.Serve(c.Response, c.Request, fileKey, filename)
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"sync"
+	"time"
+)
+
+// Registrations send a confirmation email to whatever address is typed in, so
+// they are limited per address and per client IP
+var (
+	registrationsPerEmail = newRateLimiter(3, 24*time.Hour)
+	registrationsPerIP    = newRateLimiter(10, time.Hour)
+)
+
+// rateLimiter allows up to limit events per key within a sliding window
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, hits: map[string][]time.Time{}}
+}
+
+// allow records an event for the key and reports whether it is within the limit.
+// Refused events aren't recorded, so a blocked key frees up as its window passes.
+func (l *rateLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	if len(l.hits[key]) >= l.limit {
+		return false
+	}
+	l.hits[key] = append(l.hits[key], now)
+	return true
+}
+
+// prune drops the events that fell out of the window and the keys left empty
+func (l *rateLimiter) prune(now time.Time) {
+	cutoff := now.Add(-l.window)
+	for key, hits := range l.hits {
+		i := 0
+		for i < len(hits) && !hits[i].After(cutoff) {
+			i++
+		}
+		if i == len(hits) {
+			delete(l.hits, key)
+		} else if i > 0 {
+			l.hits[key] = hits[i:]
+		}
+	}
+}
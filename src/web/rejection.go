@@ -0,0 +1,111 @@
+package web
+
+import (
+	"disciplo/src/config"
+	"disciplo/src/permissions"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var errReasonRequired = errors.New("a rejection reason is required")
+
+// rejectionInput is the body accepted by the reject endpoints
+type rejectionInput struct {
+	Reason       string `json:"reason"`        // One of [admin] rejection_reason_options
+	CustomReason string `json:"custom_reason"` // Free text, used when Reason is empty or "other"
+	Notify       bool   `json:"notify"`        // Email the applicant (needs [admin] rejection_email)
+}
+
+// resolveRejectionReason picks the configured reason or the free text entered by the admin
+func resolveRejectionReason(adminConfig config.AdminConfig, input rejectionInput) (string, error) {
+	reason := strings.TrimSpace(input.Reason)
+	customReason := strings.TrimSpace(input.CustomReason)
+
+	if reason != "" && reason != "other" {
+		for _, option := range adminConfig.RejectionOptions {
+			if option == reason {
+				return reason, nil
+			}
+		}
+		// Unknown reasons are treated as free text
+		customReason = reason
+	}
+
+	// Truncate by rune so multi-byte characters aren't cut in half
+	if runes := []rune(customReason); len(runes) > 500 {
+		customReason = string(runes[:500])
+	}
+
+	if customReason == "" && adminConfig.RejectionReasons {
+		return "", errReasonRequired
+	}
+
+	return customReason, nil
+}
+
+// rejectRequest marks a pending request as rejected by the reviewer with the given
+// reason. Requests outside the reviewer's scope are reported as not found.
+func rejectRequest(app core.App, requestId string, reviewer *permissions.Access, reason string) (*core.Record, error) {
+	var rejected *core.Record
+
+	err := app.RunInTransaction(func(txApp core.App) error {
+		request, err := txApp.FindRecordById("requests", requestId)
+		if err != nil || !reviewer.CanReview(request) {
+			return errRequestNotFound
+		}
+
+		if request.GetString("status") != "pending" {
+			return errRequestProcessed
+		}
+
+		request.Set("status", "rejected")
+		request.Set("rejected_by", reviewer.User.Id)
+		request.Set("rejected_at", types.NowDateTime())
+		request.Set("rejection_reason", reason)
+		request.Set("password", "") // No account will be created from this request
+
+		if err := txApp.Save(request); err != nil {
+			return err
+		}
+
+		rejected = request
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rejected, nil
+}
+
+// reapplyAvailableAt returns when email may apply again, if it is still inside the
+// cooldown that follows its most recent rejection. Emails are compared
+// case-insensitively, so changing the case doesn't skip the cooldown.
+func reapplyAvailableAt(app core.App, email string, cooldownDays int) (time.Time, bool) {
+	if cooldownDays <= 0 || email == "" {
+		return time.Time{}, false
+	}
+
+	records, err := app.FindRecordsByFilter(
+		"requests",
+		"email:lower = {:email} && status = 'rejected' && rejected_at != ''",
+		"-rejected_at",
+		1,
+		0,
+		map[string]interface{}{"email": strings.ToLower(strings.TrimSpace(email))},
+	)
+	if err != nil || len(records) == 0 {
+		return time.Time{}, false
+	}
+
+	availableAt := records[0].GetDateTime("rejected_at").Time().AddDate(0, 0, cooldownDays)
+	if time.Now().Before(availableAt) {
+		return availableAt, true
+	}
+
+	return time.Time{}, false
+}
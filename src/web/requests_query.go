@@ -0,0 +1,220 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	defaultRequestsPerPage = 20
+	maxRequestsPerPage     = 100
+)
+
+// requestSortOptions maps the accepted ?sort= values to ORDER BY clauses
+var requestSortOptions = map[string]string{
+	"newest": "created DESC",
+	"oldest": "created ASC",
+	"name":   "name ASC",
+	"-name":  "name DESC",
+}
+
+// requestsQuery holds the filters, search and paging for the requests queue
+type requestsQuery struct {
+	Status    string   `json:"status"` // pending (default), approved, rejected or all
+	Location  string   `json:"location"`
+	JobField  string   `json:"job_field"`
+	Interests []string `json:"interests"`
+	From      string   `json:"from"` // Submission date range, YYYY-MM-DD (inclusive)
+	To        string   `json:"to"`
+	Search    string   `json:"q"` // Free text over name, email and why_join
+	Sort      string   `json:"sort"`
+	Page      int      `json:"page"`
+	PerPage   int      `json:"per_page"`
+}
+
+// requestsPage is one page of results for a requestsQuery
+type requestsPage struct {
+	Items      []*core.Record `json:"items"`
+	Page       int            `json:"page"`
+	PerPage    int            `json:"per_page"`
+	Total      int            `json:"total"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// parseRequestsQuery reads and normalizes the queue query parameters
+func parseRequestsQuery(r *http.Request) requestsQuery {
+	values := r.URL.Query()
+
+	q := requestsQuery{
+		Status:   values.Get("status"),
+		Location: values.Get("location"),
+		JobField: values.Get("job_field"),
+		From:     values.Get("from"),
+		To:       values.Get("to"),
+		Search:   strings.TrimSpace(values.Get("q")),
+		Sort:     values.Get("sort"),
+	}
+
+	for _, interest := range values["interests"] {
+		if interest != "" {
+			q.Interests = append(q.Interests, interest)
+		}
+	}
+
+	switch q.Status {
+	case "pending", "approved", "rejected", "all":
+	default:
+		q.Status = "pending"
+	}
+
+	if _, ok := requestSortOptions[q.Sort]; !ok {
+		q.Sort = "newest"
+	}
+
+	if _, err := time.Parse("2006-01-02", q.From); err != nil {
+		q.From = ""
+	}
+	if _, err := time.Parse("2006-01-02", q.To); err != nil {
+		q.To = ""
+	}
+
+	q.Page, _ = strconv.Atoi(values.Get("page"))
+	if q.Page < 1 {
+		q.Page = 1
+	}
+
+	q.PerPage, _ = strconv.Atoi(values.Get("per_page"))
+	if q.PerPage < 1 {
+		q.PerPage = defaultRequestsPerPage
+	}
+	if q.PerPage > maxRequestsPerPage {
+		q.PerPage = maxRequestsPerPage
+	}
+
+	return q
+}
+
+// expressions builds the WHERE conditions for the query
+func (q requestsQuery) expressions() []dbx.Expression {
+	var exprs []dbx.Expression
+
+	if q.Status != "all" {
+		exprs = append(exprs, dbx.HashExp{"status": q.Status})
+	}
+	if q.Location != "" {
+		exprs = append(exprs, dbx.HashExp{"location": q.Location})
+	}
+	if q.JobField != "" {
+		exprs = append(exprs, dbx.HashExp{"job_field": q.JobField})
+	}
+
+	// Every selected interest must be present in the request's interests array
+	for i, interest := range q.Interests {
+		param := "interest" + strconv.Itoa(i)
+		exprs = append(exprs, dbx.NewExp(
+			"json_valid([[interests]]) AND EXISTS (SELECT 1 FROM json_each([[interests]]) WHERE json_each.value = {:"+param+"})",
+			dbx.Params{param: interest},
+		))
+	}
+
+	// Dates are stored as "2006-01-02 15:04:05.000Z", so plain string comparison works
+	if q.From != "" {
+		exprs = append(exprs, dbx.NewExp("[[created]] >= {:from}", dbx.Params{"from": q.From + " 00:00:00.000Z"}))
+	}
+	if q.To != "" {
+		to, _ := time.Parse("2006-01-02", q.To)
+		exprs = append(exprs, dbx.NewExp("[[created]] < {:to}", dbx.Params{"to": to.AddDate(0, 0, 1).Format("2006-01-02") + " 00:00:00.000Z"}))
+	}
+
+	if q.Search != "" {
+		exprs = append(exprs, dbx.Or(
+			dbx.Like("name", q.Search),
+			dbx.Like("email", q.Search),
+			dbx.Like("why_join", q.Search),
+		))
+	}
+
+	return exprs
+}
+
+// findRequests runs the query and returns the requested page, limited to scope
+// unless it is nil
+func findRequests(app core.App, q requestsQuery, scope dbx.Expression) (*requestsPage, error) {
+	exprs := q.expressions()
+	if scope != nil {
+		exprs = append(exprs, scope)
+	}
+
+	total, err := app.CountRecords("requests", exprs...)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*core.Record{}
+	query := app.RecordQuery("requests").
+		OrderBy(requestSortOptions[q.Sort]).
+		Limit(int64(q.PerPage)).
+		Offset(int64((q.Page - 1) * q.PerPage))
+	if len(exprs) > 0 {
+		query.AndWhere(dbx.And(exprs...))
+	}
+	if err := query.All(&records); err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(q.PerPage) - 1) / int64(q.PerPage))
+
+	return &requestsPage{
+		Items:      records,
+		Page:       q.Page,
+		PerPage:    q.PerPage,
+		Total:      int(total),
+		TotalPages: totalPages,
+	}, nil
+}
+
+// pageURL returns the queue URL for the same filters on another page
+func (q requestsQuery) pageURL(path string, page int) string {
+	values := url.Values{}
+	values.Set("status", q.Status)
+	if q.Location != "" {
+		values.Set("location", q.Location)
+	}
+	if q.JobField != "" {
+		values.Set("job_field", q.JobField)
+	}
+	for _, interest := range q.Interests {
+		values.Add("interests", interest)
+	}
+	if q.From != "" {
+		values.Set("from", q.From)
+	}
+	if q.To != "" {
+		values.Set("to", q.To)
+	}
+	if q.Search != "" {
+		values.Set("q", q.Search)
+	}
+	values.Set("sort", q.Sort)
+	values.Set("page", strconv.Itoa(page))
+	if q.PerPage != defaultRequestsPerPage {
+		values.Set("per_page", strconv.Itoa(q.PerPage))
+	}
+	return path + "?" + values.Encode()
+}
+
+// HasInterest is used by the filter form to keep selected interests checked
+func (q requestsQuery) HasInterest(interest string) bool {
+	for _, i := range q.Interests {
+		if i == interest {
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,24 @@
 package web
 
 import (
+	"disciplo/src/bot"
 	"disciplo/src/config"
 	"disciplo/src/email"
+	"disciplo/src/permissions"
+	"disciplo/src/static"
+	"disciplo/src/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
+	"log"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
 	"github.com/pocketbase/pocketbase/tools/types"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -27,10 +34,56 @@ type UserData struct {
 	Email        string
 	Status       string
 	Admin        bool
+	Role         permissions.Role
 	Verified     bool
 	Created      string
 	TelegramId   string
 	TelegramName string
+
+	// Registration profile and directory settings, only loaded for the profile page
+	City             string
+	Location         string
+	JobField         string
+	Interests        []string
+	DirectoryVisible bool
+	DirectoryFields  []string
+	GroupAdminOf     string // Name of the community the user is group admin of
+	AdminVolunteer   bool
+}
+
+// CanReview is used by the navigation to link the requests queue
+func (u *UserData) CanReview() bool {
+	return u.Role.Can(permissions.ReviewRequests)
+}
+
+// CanManageCommunities is used by the navigation to link the communities pages
+func (u *UserData) CanManageCommunities() bool {
+	return u.Role.Can(permissions.ManageCommunities)
+}
+
+// newUserData builds the base page data of the signed-in user
+func newUserData(user *core.Record) *UserData {
+	return &UserData{
+		Name:         user.GetString("name"),
+		Email:        user.GetString("email"),
+		Status:       user.GetString("status"),
+		Admin:        permissions.IsSuperadmin(user),
+		Role:         permissions.RoleOf(user),
+		Verified:     user.GetBool("verified"),
+		Created:      user.GetDateTime("created").String(),
+		TelegramId:   user.GetString("telegram_id"),
+		TelegramName: user.GetString("telegram_name"),
+	}
+}
+
+// ShowsField is used by the profile page to keep the shared directory fields checked
+func (u *UserData) ShowsField(field string) bool {
+	return containsString(u.DirectoryFields, field)
+}
+
+// DirectoryFieldOptions lists the fields a member can share in the directory
+func (PageData) DirectoryFieldOptions() []directoryField {
+	return directoryFields
 }
 
 type PageData struct {
@@ -40,6 +93,48 @@ type PageData struct {
 	BotUsername string
 }
 
+// CommunitiesPageData is the data of the communities list page
+type CommunitiesPageData struct {
+	PageData
+	Communities  []communitySummary
+	ShowArchived bool
+	CanConfigure bool // Whether the user can create communities and run the local assignment
+	Types        []string
+	Locations    []string
+}
+
+// CommunityPageData is the data of a community's page
+type CommunityPageData struct {
+	PageData
+	Community    communitySummary
+	Members      []communityMember
+	Candidates   []communityMember // Accepted users who are not members yet, from the user's regions
+	Types        []string
+	Locations    []string
+	BotRunning   bool
+	CanConfigure bool // Whether the user can change the type, regions and archived state
+	ShowEmails   bool // Whether candidates are listed with their email
+}
+
+// LocalAssignmentPageData is the data of the local assignment preview page
+type LocalAssignmentPageData struct {
+	PageData
+	Rows     []localAssignmentRow
+	Fallback string
+}
+
+// DirectoryPageData is the data of the member directory page
+type DirectoryPageData struct {
+	PageData
+	AdminView bool
+	Listed    bool // Whether the viewer is listed in the member view
+	Query     directoryQuery
+	Members   []memberCard
+	Locations []string
+	JobFields []string
+	Interests []string
+}
+
 // Authentication helper function using PocketBase's native methods
 func getAuthenticatedUser(c *core.RequestEvent) *core.Record {
 	var token string
@@ -77,15 +172,38 @@ func min(a, b int) int {
 	return b
 }
 
-// Check if user is authenticated and is admin
+// isBcryptHash reports whether value looks like a bcrypt hash ($2a$, $2b$, $2y$)
+func isBcryptHash(value string) bool {
+	if _, err := bcrypt.Cost([]byte(value)); err != nil {
+		return false
+	}
+	return true
+}
+
+// Check if user is authenticated and is a superadmin
 func requireAdmin(c *core.RequestEvent) *core.Record {
 	user := getAuthenticatedUser(c)
-	if user == nil || !user.GetBool("admin") {
+	if user == nil || !permissions.IsSuperadmin(user) {
 		return nil
 	}
 	return user
 }
 
+// Check if user is authenticated and their role grants the permission. The access
+// returned carries their scope for the per-record checks.
+func requirePermission(c *core.RequestEvent, p permissions.Permission) (*core.Record, *permissions.Access) {
+	user := getAuthenticatedUser(c)
+	if user == nil || !permissions.RoleOf(user).Can(p) {
+		return nil, nil
+	}
+	access, err := permissions.Resolve(c.App, user)
+	if err != nil {
+		fmt.Printf("Warning: Failed to resolve permissions of %s: %v\n", user.GetString("email"), err)
+		return nil, nil
+	}
+	return user, access
+}
+
 // Authentication middleware for regular users
 func requireAuth(next func(*core.RequestEvent) error) func(*core.RequestEvent) error {
 	return func(c *core.RequestEvent) error {
@@ -142,7 +260,7 @@ func redirectAuthenticatedUsers(next func(*core.RequestEvent) error) func(*core.
 		// Check if user is already authenticated using PocketBase patterns
 		if user := getAuthenticatedUser(e); user != nil {
 			// Redirect admin users to admin dashboard
-			if user.GetBool("admin") {
+			if permissions.IsSuperadmin(user) {
 				return e.Redirect(http.StatusFound, "/admin/dashboard")
 			}
 			// Redirect regular users to user dashboard
@@ -153,8 +271,40 @@ func redirectAuthenticatedUsers(next func(*core.RequestEvent) error) func(*core.
 	}
 }
 
-func SetupRoutes(app core.App, cfg *config.Config) {
+// SetupRoutes parses the page templates and registers the web routes. Templates
+// in the web.template_path directory replace the embedded ones; in dev mode pages
+// are parsed on every request, from the source tree when there is one.
+func SetupRoutes(app core.App, cfg *config.Config) error {
+	overrideDir := static.DefaultOverrideDir
+	localFallback := ""
+	if disciploConfig, err := config.LoadDisciploConfig(); err == nil {
+		if disciploConfig.Web.TemplatePath != "" {
+			overrideDir = disciploConfig.Web.TemplatePath
+		}
+		localFallback = disciploConfig.Communities.LocalFallback
+	}
+	for _, name := range static.Overrides(overrideDir) {
+		log.Printf("📝 %s overrides the embedded %s", filepath.Join(overrideDir, name), name)
+	}
+	dirs := []string{overrideDir}
+	if cfg.DevMode {
+		dirs = append(dirs, static.SourceDir())
+	}
+	pages, err := newPageCache(static.FS(dirs...), cfg.DevMode)
+	if err != nil {
+		return err
+	}
+
+	registerLocalAssignmentHooks(app, localFallback)
+
 	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		// Load disciplo configuration
+		disciploConfig, err := config.LoadDisciploConfig()
+		if err != nil {
+			// Use default config if loading fails
+			disciploConfig = &config.DisciploConfig{}
+		}
+
 		// Root route - redirect authenticated users to dashboard, others to login
 		e.Router.GET("/", redirectAuthenticatedUsers(func(c *core.RequestEvent) error {
 			return c.Redirect(http.StatusFound, "/login")
@@ -162,17 +312,7 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 
 		// Login page - use middleware to redirect authenticated users
 		e.Router.GET("/login", redirectAuthenticatedUsers(func(c *core.RequestEvent) error {
-			tmpl, err := template.ParseFiles("pb_public/templates/login.html")
-			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
-			}
-			
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, nil); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
-			}
-			
-			return c.HTML(http.StatusOK, buf.String())
+			return pages.render(c, http.StatusOK, "login", nil)
 		}))
 
 		// Logout route - clear session and redirect
@@ -234,20 +374,11 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 			}
 			
 			// Redirect admin users to admin dashboard
-			if user.GetBool("admin") {
+			if permissions.IsSuperadmin(user) {
 				return c.Redirect(http.StatusFound, "/admin/dashboard")
 			}
 
-			userData := &UserData{
-				Name:         user.GetString("name"),
-				Email:        user.GetString("email"),
-				Status:       user.GetString("status"),
-				Admin:        user.GetBool("admin"),
-				Verified:     user.GetBool("verified"),
-				Created:      user.GetDateTime("created").String(),
-				TelegramId:   user.GetString("telegram_id"),
-				TelegramName: user.GetString("telegram_name"),
-			}
+			userData := newUserData(user)
 
 			pageData := PageData{
 				PageTitle:   "Dashboard",
@@ -256,17 +387,7 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				BotUsername: cfg.BotUsername,
 			}
 
-			tmpl, err := template.ParseFiles("pb_public/templates/base.html", "pb_public/templates/dashboard.html")
-			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
-			}
-			
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, pageData); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
-			}
-			
-			return c.HTML(http.StatusOK, buf.String())
+			return pages.render(c, http.StatusOK, "dashboard", pageData)
 		})
 
 		// Profile page - PROTECTED
@@ -276,15 +397,17 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				return c.Redirect(http.StatusFound, "/login")
 			}
 
-			userData := &UserData{
-				Name:         user.GetString("name"),
-				Email:        user.GetString("email"),
-				Status:       user.GetString("status"),
-				Admin:        user.GetBool("admin"),
-				Verified:     user.GetBool("verified"),
-				Created:      user.GetDateTime("created").String(),
-				TelegramId:   user.GetString("telegram_id"),
-				TelegramName: user.GetString("telegram_name"),
+			userData := newUserData(user)
+
+			userData.City = user.GetString("city")
+			userData.Location = user.GetString("location")
+			userData.JobField = user.GetString("job_field")
+			userData.Interests = user.GetStringSlice("interests")
+			userData.DirectoryVisible = user.GetBool("directory_visible")
+			userData.DirectoryFields = user.GetStringSlice("directory_fields")
+			userData.AdminVolunteer = user.GetBool("admin_volunteer")
+			if community, err := e.App.FindRecordById("communities", user.GetString("group_admin")); err == nil {
+				userData.GroupAdminOf = community.GetString("name")
 			}
 
 			pageData := PageData{
@@ -294,99 +417,200 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				BotUsername: cfg.BotUsername,
 			}
 
-			tmpl, err := template.ParseFiles("pb_public/templates/base.html", "pb_public/templates/profile.html")
-			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
-			}
-			
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, pageData); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
-			}
-			
-			return c.HTML(http.StatusOK, buf.String())
+			return pages.render(c, http.StatusOK, "profile", pageData)
 		})
 
-		// Communities page - PROTECTED (admin only)
+		// Communities page - PROTECTED (community managers, scoped to their communities)
 		e.Router.GET("/communities", func(c *core.RequestEvent) error {
-			user := requireAdmin(c)
+			user, access := requirePermission(c, permissions.ManageCommunities)
 			if user == nil {
 				return c.Redirect(http.StatusFound, "/login")
 			}
 
-			userData := &UserData{
-				Name:         user.GetString("name"),
-				Email:        user.GetString("email"),
-				Status:       user.GetString("status"),
-				Admin:        user.GetBool("admin"),
-				Verified:     user.GetBool("verified"),
-				Created:      user.GetDateTime("created").String(),
-				TelegramId:   user.GetString("telegram_id"),
-				TelegramName: user.GetString("telegram_name"),
+			showArchived := c.Request.URL.Query().Get("archived") == "1"
+			communities, err := findCommunities(e.App, showArchived, access.CommunitiesExpr())
+			if err != nil {
+				fmt.Printf("Error loading communities: %v\n", err)
 			}
 
-			pageData := PageData{
-				PageTitle:   "Communities",
-				AppName:     cfg.AppName,
-				User:        userData,
-				BotUsername: cfg.BotUsername,
+			data := CommunitiesPageData{
+				PageData: PageData{
+					PageTitle:   "Communities",
+					AppName:     cfg.AppName,
+					User:        newUserData(user),
+					BotUsername: cfg.BotUsername,
+				},
+				Communities:  communities,
+				ShowArchived: showArchived,
+				CanConfigure: access.Can(permissions.ConfigureCommunities),
+				Types:        communityTypes,
+				Locations:    disciploConfig.Registration.Locations.Options,
+			}
+
+			return pages.render(c, http.StatusOK, "communities", data)
+		})
+
+		// Local community assignment preview - PROTECTED (superadmin only)
+		e.Router.GET("/communities/local-assignment", func(c *core.RequestEvent) error {
+			user, _ := requirePermission(c, permissions.ConfigureCommunities)
+			if user == nil {
+				return c.Redirect(http.StatusFound, "/login")
 			}
 
-			tmpl, err := template.ParseFiles("pb_public/templates/base.html", "pb_public/templates/communities.html")
+			plans, err := planLocalAssignments(e.App, localFallback)
 			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
+				fmt.Printf("Error planning local assignments: %v\n", err)
 			}
-			
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, pageData); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
+			rows := []localAssignmentRow{}
+			for _, plan := range plans {
+				rows = append(rows, newLocalAssignmentRow(plan))
 			}
-			
-			return c.HTML(http.StatusOK, buf.String())
+
+			data := LocalAssignmentPageData{
+				PageData: PageData{
+					PageTitle:   "Local Assignment",
+					AppName:     cfg.AppName,
+					User:        newUserData(user),
+					BotUsername: cfg.BotUsername,
+				},
+				Rows:     rows,
+				Fallback: localFallback,
+			}
+
+			return pages.render(c, http.StatusOK, "local_assignment", data)
 		})
 
-		// Members page - PROTECTED (admin only)
-		e.Router.GET("/members", func(c *core.RequestEvent) error {
-			user := requireAdmin(c)
+		// Community page with its members - PROTECTED (community managers)
+		e.Router.GET("/communities/{id}", func(c *core.RequestEvent) error {
+			user, access := requirePermission(c, permissions.ManageCommunities)
 			if user == nil {
 				return c.Redirect(http.StatusFound, "/login")
 			}
 
-			userData := &UserData{
-				Name:         user.GetString("name"),
-				Email:        user.GetString("email"),
-				Status:       user.GetString("status"),
-				Admin:        user.GetBool("admin"),
-				Verified:     user.GetBool("verified"),
-				Created:      user.GetDateTime("created").String(),
-				TelegramId:   user.GetString("telegram_id"),
-				TelegramName: user.GetString("telegram_name"),
+			community, err := e.App.FindRecordById("communities", c.Request.PathValue("id"))
+			if err != nil || !access.CanManage(community) {
+				return c.Redirect(http.StatusFound, "/communities")
 			}
 
-			pageData := PageData{
-				PageTitle:   "Members",
-				AppName:     cfg.AppName,
-				User:        userData,
-				BotUsername: cfg.BotUsername,
+			members, candidates, err := findCommunityMembers(e.App, community.Id, access.MembersExpr())
+			if err != nil {
+				fmt.Printf("Error loading community members: %v\n", err)
+			}
+
+			data := CommunityPageData{
+				PageData: PageData{
+					PageTitle:   community.GetString("name"),
+					AppName:     cfg.AppName,
+					User:        newUserData(user),
+					BotUsername: cfg.BotUsername,
+				},
+				Community:    newCommunitySummary(e.App, community),
+				Members:      members,
+				Candidates:   candidates,
+				Types:        communityTypes,
+				Locations:    disciploConfig.Registration.Locations.Options,
+				BotRunning:   bot.Running() != nil,
+				CanConfigure: access.Can(permissions.ConfigureCommunities),
+				ShowEmails:   access.Can(permissions.ViewAllMembers),
+			}
+
+			return pages.render(c, http.StatusOK, "community", data)
+		})
+
+		// Community management API - superadmins create and configure communities,
+		// community admins manage the ones in their scope
+		e.Router.POST("/api/admin/communities", func(c *core.RequestEvent) error {
+			if user, _ := requirePermission(c, permissions.ConfigureCommunities); user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
 			}
 
-			tmpl, err := template.ParseFiles("pb_public/templates/base.html", "pb_public/templates/members.html")
+			collection, err := e.App.FindCollectionByNameOrId("communities")
 			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
+				return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Communities collection not found"})
 			}
-			
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, pageData); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
+
+			return handleSaveCommunity(c, disciploConfig, core.NewRecord(collection), true)
+		})
+
+		communityAPI := func(p permissions.Permission, handler func(c *core.RequestEvent, community *core.Record) error) func(*core.RequestEvent) error {
+			return func(c *core.RequestEvent) error {
+				user, access := requirePermission(c, p)
+				if user == nil {
+					return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
+				}
+
+				// Communities outside the user's scope are reported as missing
+				community, err := e.App.FindRecordById("communities", c.Request.PathValue("id"))
+				if err != nil || !access.CanManage(community) {
+					return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Community not found"})
+				}
+
+				return handler(c, community)
 			}
-			
-			return c.HTML(http.StatusOK, buf.String())
+		}
+
+		e.Router.PUT("/api/admin/communities/{id}", communityAPI(permissions.ManageCommunities, func(c *core.RequestEvent, community *core.Record) error {
+			configure := permissions.RoleOf(getAuthenticatedUser(c)).Can(permissions.ConfigureCommunities)
+			return handleSaveCommunity(c, disciploConfig, community, configure)
+		}))
+		e.Router.POST("/api/admin/communities/{id}/archive", communityAPI(permissions.ConfigureCommunities, handleArchiveCommunity))
+		e.Router.POST("/api/admin/communities/{id}/members", communityAPI(permissions.ManageCommunities, handleAddCommunityMember))
+		e.Router.DELETE("/api/admin/communities/{id}/members/{userId}", communityAPI(permissions.ManageCommunities, handleRemoveCommunityMember))
+		e.Router.GET("/api/admin/communities/{id}/member-count", communityAPI(permissions.ManageCommunities, handleCommunityMemberCount))
+
+		e.Router.POST("/api/admin/local-assignment", func(c *core.RequestEvent) error {
+			if user, _ := requirePermission(c, permissions.ConfigureCommunities); user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
+			}
+			return handleApplyLocalAssignments(c, localFallback)
 		})
 
-		// Admin requests page - PROTECTED
-		e.Router.GET("/admin/requests", func(c *core.RequestEvent) error {
+		// Member directory - PROTECTED. Admins see every verified member; members
+		// who listed themselves see the others who did, with the fields they share
+		e.Router.GET("/members", func(c *core.RequestEvent) error {
 			user := getAuthenticatedUser(c)
-			if user == nil || !user.GetBool("admin") {
+			if user == nil {
+				return c.Redirect(http.StatusFound, "/login")
+			}
+
+			adminView := permissions.RoleOf(user).Can(permissions.ViewAllMembers)
+			if !adminView && (user.GetString("status") != "accepted" || !user.GetBool("verified")) {
+				return c.Redirect(http.StatusFound, "/dashboard")
+			}
+
+			userData := newUserData(user)
+
+			data := DirectoryPageData{
+				PageData: PageData{
+					PageTitle:   "Members",
+					AppName:     cfg.AppName,
+					User:        userData,
+					BotUsername: cfg.BotUsername,
+				},
+				AdminView: adminView,
+				Listed:    user.GetBool("directory_visible"),
+				Query:     parseDirectoryQuery(c.Request),
+				Locations: disciploConfig.Registration.Locations.Options,
+				JobFields: disciploConfig.Registration.JobFields.Options,
+				Interests: disciploConfig.Registration.Interests.Options,
+			}
+
+			// Members only see the directory once they are listed themselves
+			if adminView || data.Listed {
+				members, err := findDirectory(e.App, data.Query, adminView)
+				if err != nil {
+					fmt.Printf("Error loading member directory: %v\n", err)
+				}
+				data.Members = members
+			}
+
+			return pages.render(c, http.StatusOK, "members", data)
+		})
+
+		// Admin requests page - PROTECTED (reviewers, scoped to their regions)
+		e.Router.GET("/admin/requests", func(c *core.RequestEvent) error {
+			user, access := requirePermission(c, permissions.ReviewRequests)
+			if user == nil {
 				return c.Redirect(http.StatusFound, "/login")
 			}
 
@@ -396,15 +620,14 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				disciploConfig = &config.DisciploConfig{}
 			}
 
-			// Get pending requests
-			requests, err := e.App.FindRecordsByFilter("requests", "status = 'pending'", "", 50, 0)
+			// Filtered, searchable and paginated queue
+			query := parseRequestsQuery(c.Request)
+			page, err := findRequests(e.App, query, access.RequestsExpr())
 			if err != nil {
 				// Log error for debugging
 				fmt.Printf("Error finding requests: %v\n", err)
 				// Handle error but continue with empty list
-				requests = []*core.Record{}
-			} else {
-				fmt.Printf("Found %d requests with status='pending'\n", len(requests))
+				page = &requestsPage{Items: []*core.Record{}, Page: query.Page, PerPage: query.PerPage}
 			}
 
 			data := struct {
@@ -413,33 +636,38 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				Requests     []*core.Record
 				AppName      string
 				Config       *config.DisciploConfig
+				Query        requestsQuery
+				Page         *requestsPage
+				PrevURL      string
+				NextURL      string
 			}{
 				AdminEmail:   user.GetString("email"),
 				AdminName:    user.GetString("name"),
-				Requests:     requests,
+				Requests:     page.Items,
 				AppName:      disciploConfig.General.AppName,
 				Config:       disciploConfig,
+				Query:        query,
+				Page:         page,
 			}
-
-			tmpl, err := template.ParseFiles("pb_public/templates/admin_requests.html")
-			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
+			if page.Page > 1 {
+				data.PrevURL = query.pageURL("/admin/requests", page.Page-1)
 			}
-
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, data); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
+			if page.Page < page.TotalPages {
+				data.NextURL = query.pageURL("/admin/requests", page.Page+1)
 			}
 
-			return c.HTML(http.StatusOK, buf.String())
+			return pages.render(c, http.StatusOK, "admin_requests", data)
 		})
 
-		// Admin dashboard - PROTECTED
+		// Admin dashboard - PROTECTED (superadmin only)
 		e.Router.GET("/admin/dashboard", func(c *core.RequestEvent) error {
 			user := getAuthenticatedUser(c)
-			if user == nil || !user.GetBool("admin") {
+			if user == nil {
 				return c.Redirect(http.StatusFound, "/login")
 			}
+			if !permissions.IsSuperadmin(user) {
+				return c.Redirect(http.StatusFound, "/dashboard")
+			}
 			
 			// Generate fresh token for admin only if they don't have one or it's expired
 			var telegramLink string
@@ -466,17 +694,7 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				TelegramLink: telegramLink,
 			}
 
-			tmpl, err := template.ParseFiles("pb_public/templates/admin_dashboard.html")
-			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
-			}
-
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, data); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
-			}
-
-			return c.HTML(http.StatusOK, buf.String())
+			return pages.render(c, http.StatusOK, "admin_dashboard", data)
 		})
 		
 		// API endpoint to check telegram status - PROTECTED
@@ -582,102 +800,175 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 			})
 		})
 
-		// API endpoint to approve membership request - ADMIN ONLY
-		e.Router.POST("/api/admin/approve-request/{id}", func(c *core.RequestEvent) error {
-			user := requireAdmin(c)
+		// Member directory settings
+		e.Router.PUT("/api/profile/directory", func(c *core.RequestEvent) error {
+			user := getAuthenticatedUser(c)
 			if user == nil {
-				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"success": false,
+					"error":   "Not authenticated",
+				})
 			}
 
-			requestId := c.Request.PathValue("id")
-			if requestId == "" {
-				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Request ID is required"})
+			var settings struct {
+				Visible bool     `json:"visible"`
+				Fields  []string `json:"fields"`
 			}
-			
-			// Find the request
-			request, err := e.App.FindRecordById("requests", requestId)
-			if err != nil {
-				return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Request not found"})
+
+			if err := c.BindBody(&settings); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{
+					"success": false,
+					"error":   "Invalid request data",
+				})
 			}
 
-			// Check if request is already processed
-			if request.GetString("status") != "pending" {
-				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Request has already been processed"})
+			user.Set("directory_visible", settings.Visible)
+			user.Set("directory_fields", validDirectoryFields(settings.Fields))
+
+			if err := e.App.Save(user); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+					"success": false,
+					"error":   "Failed to update directory settings",
+				})
 			}
 
-			// Create user account in PocketBase's auth collection
-			authCollection, err := e.App.FindCollectionByNameOrId("_pb_users_auth_")
-			if err != nil {
-				return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to find auth collection"})
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"success": true,
+			})
+		})
+
+		// Group admin rotation volunteering
+		e.Router.PUT("/api/profile/admin-volunteer", func(c *core.RequestEvent) error {
+			user := getAuthenticatedUser(c)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"success": false,
+					"error":   "Not authenticated",
+				})
 			}
 
-			newUser := core.NewRecord(authCollection)
-			
-			// Generate a temporary password for the user - they will reset it via Telegram
-			tempPassword, _ := gonanoid.New(16)
-			
-			// Set basic auth data from request
-			newUser.Set("name", request.GetString("name"))
-			newUser.Set("email", request.GetString("email"))
-			newUser.Set("password", tempPassword) // PocketBase will hash this automatically
-			newUser.Set("emailVisibility", false)
-			// User starts with verified=false until Telegram is linked
-			
-			// Set additional fields for our platform
-			newUser.Set("admin", false)
-			newUser.Set("status", "accepted")
-			newUser.Set("telegram_id", "")
-			newUser.Set("telegram_name", "")
-			newUser.Set("groups", "")
-			newUser.Set("group_admin", "")
-			newUser.Set("group_admin_since", "")
-
-			// Save the new user
-			if err := e.App.Save(newUser); err != nil {
+			var settings struct {
+				Volunteer bool `json:"volunteer"`
+			}
+
+			if err := c.BindBody(&settings); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{
+					"success": false,
+					"error":   "Invalid request data",
+				})
+			}
+
+			user.Set("admin_volunteer", settings.Volunteer)
+
+			if err := e.App.Save(user); err != nil {
 				return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-					"error": "Failed to create user account: " + err.Error(),
+					"success": false,
+					"error":   "Failed to update volunteer setting",
 				})
 			}
 
-			// Generate and save Telegram token for user
-			token, _ := gonanoid.New(21)
-			newUser.Set("telegram_token", token)
-			if err := e.App.Save(newUser); err != nil {
-				fmt.Printf("Warning: Failed to save telegram token: %v\n", err)
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"success": true,
+			})
+		})
+
+		// JSON twin of the requests page, same query parameters - REVIEWERS ONLY
+		e.Router.GET("/api/admin/requests", func(c *core.RequestEvent) error {
+			user, access := requirePermission(c, permissions.ReviewRequests)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
 			}
-			
-			// Send welcome email with Telegram bot link
-			if err := email.SendApprovalWelcome(e.App, request.GetString("email"), request.GetString("name"), cfg.BotUsername, token); err != nil {
-				fmt.Printf("Warning: Failed to send welcome email: %v\n", err)
-				// Continue with approval process even if email fails
+
+			query := parseRequestsQuery(c.Request)
+			page, err := findRequests(e.App, query, access.RequestsExpr())
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to load requests"})
 			}
 
-			// Update request with approval details
-			request.Set("status", "approved")
-			request.Set("approved_by", user.Id)
-			request.Set("approved_at", types.NowDateTime())
-			request.Set("created_user_id", newUser.Id)
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"query":       query,
+				"items":       page.Items,
+				"page":        page.Page,
+				"per_page":    page.PerPage,
+				"total":       page.Total,
+				"total_pages": page.TotalPages,
+			})
+		})
+
+		// API endpoint to approve membership request - REVIEWERS ONLY
+		e.Router.POST("/api/admin/approve-request/{id}", func(c *core.RequestEvent) error {
+			user, access := requirePermission(c, permissions.ReviewRequests)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
+			}
+
+			requestId := c.Request.PathValue("id")
+			if requestId == "" {
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Request ID is required"})
+			}
 			
-			if err := e.App.Save(request); err != nil {
-				// If request update fails, we should consider rolling back user creation
-				// For simplicity, we'll log the error but continue
-				fmt.Printf("Warning: Failed to update request after user creation: %v\n", err)
+			// Create the user and approve the request atomically
+			result, err := approveRequest(e.App, requestId, access)
+			switch {
+			case errors.Is(err, errRequestNotFound):
+				return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Request not found"})
+			case errors.Is(err, errRequestProcessed):
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Request has already been processed"})
+			case err != nil:
+				return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+					"error": "Failed to approve request: " + err.Error(),
+				})
 			}
 
-			// TODO: Send welcome email to the new user
-			// TODO: Send notification to admin about successful approval
+			// Send welcome email with Telegram bot link only once the approval is committed
+			emailData := email.NewTemplateData(cfg, disciploConfig, result.Request)
+			emailData.SetTelegramToken(result.TelegramToken)
+			if err := email.SendApprovalWelcome(e.App, disciploConfig, emailData); err != nil {
+				fmt.Printf("Warning: Failed to send welcome email: %v\n", err)
+				// The approval stands even if email fails
+			}
 
-			fmt.Printf("Request %s approved and user %s created successfully\n", requestId, newUser.Id)
+			fmt.Printf("Request %s approved and user %s created successfully\n", requestId, result.User.Id)
 
 			return c.JSON(http.StatusOK, map[string]interface{}{
 				"success": true,
-				"user_id": newUser.Id,
+				"user_id": result.User.Id,
 			})
 		})
 
-		// API endpoint to reject membership request - ADMIN ONLY
+		// API endpoint to approve or reject many requests at once - REVIEWERS ONLY
+		e.Router.POST("/api/admin/requests/bulk", func(c *core.RequestEvent) error {
+			user, access := requirePermission(c, permissions.ReviewRequests)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
+			}
+
+			return handleBulkRequests(c, cfg, disciploConfig, access)
+		})
+
+		// API endpoint to view a request's profile picture - REVIEWERS ONLY
+		e.Router.GET("/api/admin/requests/{id}/picture", func(c *core.RequestEvent) error {
+			user, access := requirePermission(c, permissions.ReviewRequests)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
+			}
+
+			request, err := e.App.FindRecordById("requests", c.Request.PathValue("id"))
+			if err != nil || !access.CanReview(request) {
+				return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Request not found"})
+			}
+
+			thumb := ""
+			if c.Request.URL.Query().Get("thumb") != "" {
+				thumb = "150x150"
+			}
+
+			return serveRecordFile(c, request, "profile_picture", thumb)
+		})
+
+		// API endpoint to reject membership request - REVIEWERS ONLY
 		e.Router.POST("/api/admin/reject-request/{id}", func(c *core.RequestEvent) error {
-			user := requireAdmin(c)
+			user, access := requirePermission(c, permissions.ReviewRequests)
 			if user == nil {
 				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Admin access required"})
 			}
@@ -687,21 +978,44 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Request ID is required"})
 			}
 			
-			// Find the request
-			request, err := e.App.FindRecordById("requests", requestId)
+			// Body is optional so the endpoint still works without a reason when reasons are disabled
+			var input rejectionInput
+			if c.Request.ContentLength > 0 {
+				if err := c.BindBody(&input); err != nil {
+					return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Invalid request data"})
+				}
+			}
+
+			reason, err := resolveRejectionReason(disciploConfig.Admin, input)
 			if err != nil {
-				return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Request not found"})
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "A rejection reason is required"})
 			}
 
-			// Update request status to rejected
-			request.Set("status", "rejected")
-			if err := e.App.Save(request); err != nil {
+			request, err := rejectRequest(e.App, requestId, access, reason)
+			switch {
+			case errors.Is(err, errRequestNotFound):
+				return c.JSON(http.StatusNotFound, map[string]interface{}{"error": "Request not found"})
+			case errors.Is(err, errRequestProcessed):
+				return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "Request has already been processed"})
+			case err != nil:
 				return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": "Failed to update request"})
 			}
 
-			// TODO: Send rejection email (if implemented)
+			// Optionally let the applicant know, once the rejection is saved
+			emailed := false
+			if input.Notify && disciploConfig.Admin.RejectionEmail {
+				emailData := email.NewTemplateData(cfg, disciploConfig, request)
+				if err := email.SendRejection(e.App, disciploConfig, emailData); err != nil {
+					fmt.Printf("Warning: Failed to send rejection email: %v\n", err)
+				} else {
+					emailed = true
+				}
+			}
 
-			return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"success": true,
+				"emailed": emailed,
+			})
 		})
 
 		// API endpoint for password change - PROTECTED
@@ -755,13 +1069,6 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 			})
 		})
 
-		// Load disciplo configuration
-		disciploConfig, err := config.LoadDisciploConfig()
-		if err != nil {
-			// Use default config if loading fails
-			disciploConfig = &config.DisciploConfig{}
-		}
-
 		// Registration page - use middleware to redirect authenticated users
 		e.Router.GET("/register", redirectAuthenticatedUsers(func(c *core.RequestEvent) error {
 			if !disciploConfig.Registration.Enabled {
@@ -799,17 +1106,7 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				Steps:          steps,
 			}
 
-			tmpl, err := template.ParseFiles("pb_public/templates/register.html")
-			if err != nil {
-				return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
-			}
-
-			var buf strings.Builder
-			if err := tmpl.Execute(&buf, data); err != nil {
-				return c.String(http.StatusInternalServerError, "Template error")
-			}
-
-			return c.HTML(http.StatusOK, buf.String())
+			return pages.render(c, http.StatusOK, "register", data)
 		}))
 
 		// Registration API endpoint
@@ -832,6 +1129,22 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 			interestsJSON := c.Request.FormValue("interests")
 			whyJoin := c.Request.FormValue("why_join")
 
+			// Limit how many confirmation emails one address or client can trigger
+			if !registrationsPerIP.allow(c.RealIP()) || !registrationsPerEmail.allow(strings.ToLower(strings.TrimSpace(userEmail))) {
+				return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+					"success": false,
+					"error":   "Too many registration attempts, please try again later",
+				})
+			}
+
+			// Enforce the re-application cooldown after a rejection
+			if availableAt, blocked := reapplyAvailableAt(e.App, userEmail, disciploConfig.Admin.ReapplyCooldownDays); blocked {
+				return c.JSON(http.StatusForbidden, map[string]interface{}{
+					"success": false,
+					"error":   "You can submit a new application from " + availableAt.Format("2006-01-02"),
+				})
+			}
+
 			// Validate password strength
 			if len(password) < 8 {
 				return c.JSON(http.StatusBadRequest, map[string]interface{}{
@@ -858,15 +1171,24 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				})
 			}
 
-			// Optional file size validation (if file is uploaded)
-			if c.Request.MultipartForm != nil && c.Request.MultipartForm.File["profile_picture"] != nil {
-				fileHeaders := c.Request.MultipartForm.File["profile_picture"]
-				if len(fileHeaders) > 0 && fileHeaders[0].Size > int64(disciploConfig.Registration.Picture.MaxSizeMB*1024*1024) {
+			// Optional profile picture: validate, sniff and resize before anything is saved
+			var profilePicture *filesystem.File
+			if c.Request.MultipartForm != nil && len(c.Request.MultipartForm.File["profile_picture"]) > 0 {
+				fileHeader := c.Request.MultipartForm.File["profile_picture"][0]
+				if fileHeader.Size > int64(disciploConfig.Registration.Picture.MaxSizeMB*1024*1024) {
 					return c.JSON(http.StatusBadRequest, map[string]interface{}{
 						"success": false,
 						"error":   fmt.Sprintf("File size must be less than %dMB", disciploConfig.Registration.Picture.MaxSizeMB),
 					})
 				}
+
+				profilePicture, err = readProfilePicture(fileHeader, disciploConfig.Registration.Picture)
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, map[string]interface{}{
+						"success": false,
+						"error":   "Invalid profile picture: " + err.Error(),
+					})
+				}
 			}
 
 			// Create new request record
@@ -902,6 +1224,10 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 			record.Set("interests", interests)
 			record.Set("why_join", whyJoin)
 			record.Set("status", "pending")
+			record.Set("status_token", utils.GenerateToken())
+			if profilePicture != nil {
+				record.Set("profile_picture", profilePicture)
+			}
 
 			// Save the record  
 			if err := e.App.Save(record); err != nil {
@@ -911,23 +1237,68 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				})
 			}
 
-			// Handle file upload - for now we skip complex file processing
-			// Files will be handled by the form validation above
-			// TODO: Implement proper file upload with PocketBase file handling
-
 			// Send email notification to admin
-			if err := email.SendNewRegistrationNotification(e.App, disciploConfig.General.EmailRequests, name, userEmail); err != nil {
+			emailData := email.NewTemplateData(cfg, disciploConfig, record)
+			if err := email.SendNewRegistrationNotification(e.App, disciploConfig, disciploConfig.General.EmailRequests, emailData); err != nil {
 				// Log error but don't fail the registration
 				fmt.Printf("Failed to send admin notification email: %v\n", err)
 			}
 
+			// Send confirmation email to the applicant
+			if err := email.SendRegistrationReceived(e.App, disciploConfig, emailData); err != nil {
+				fmt.Printf("Failed to send registration confirmation email: %v\n", err)
+			}
+
 			return c.JSON(http.StatusOK, map[string]interface{}{
 				"success":    true,
 				"message":    "Registration submitted successfully",
 				"request_id": record.Id,
+				"status_url": emailData.StatusURL,
 			})
 		})
 
+		// Public request status page - keyed by the unguessable token emailed to the applicant
+		e.Router.GET("/request-status/{token}", func(c *core.RequestEvent) error {
+			data := struct {
+				AppName     string
+				Found       bool
+				Name        string
+				RequestID   string
+				Status      string
+				Submitted   string
+				DecidedAt   string
+			}{
+				AppName: disciploConfig.General.AppName,
+			}
+
+			token := c.Request.PathValue("token")
+			if token != "" {
+				request, err := e.App.FindFirstRecordByFilter("requests", "status_token = {:token}", map[string]interface{}{
+					"token": token,
+				})
+				if err == nil && request != nil {
+					data.Found = true
+					data.Name = request.GetString("name")
+					data.RequestID = request.Id
+					data.Status = request.GetString("status")
+					data.Submitted = request.GetDateTime("created").Time().Format("2006-01-02")
+					if approvedAt := request.GetDateTime("approved_at"); !approvedAt.IsZero() {
+						data.DecidedAt = approvedAt.Time().Format("2006-01-02")
+					}
+					if rejectedAt := request.GetDateTime("rejected_at"); !rejectedAt.IsZero() {
+						data.DecidedAt = rejectedAt.Time().Format("2006-01-02")
+					}
+				}
+			}
+
+			c.Response.Header().Set("Cache-Control", "no-store")
+			status := http.StatusOK
+			if !data.Found {
+				status = http.StatusNotFound
+			}
+			return pages.render(c, status, "request_status", data)
+		})
+
 		// Email check API endpoint for duplicate validation
 		e.Router.POST("/api/check-email", func(c *core.RequestEvent) error {
 			email := c.Request.FormValue("email")
@@ -963,6 +1334,15 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 				})
 			}
 
+			// Check if email was rejected recently and is still in the cooldown period
+			if availableAt, blocked := reapplyAvailableAt(e.App, email, disciploConfig.Admin.ReapplyCooldownDays); blocked {
+				return c.JSON(http.StatusOK, map[string]interface{}{
+					"exists":  true,
+					"type":    "cooldown",
+					"message": "A recent application for this email was not accepted. You can apply again from " + availableAt.Format("2006-01-02") + ".",
+				})
+			}
+
 			// Email is available
 			return c.JSON(http.StatusOK, map[string]interface{}{
 				"exists":  false,
@@ -976,7 +1356,7 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 			// Check authentication for undefined routes
 			if user := getAuthenticatedUser(c); user != nil {
 				// Authenticated user accessing undefined route → redirect to appropriate dashboard
-				if user.GetBool("admin") {
+				if permissions.IsSuperadmin(user) {
 					return c.Redirect(http.StatusFound, "/admin/dashboard")
 				}
 				return c.Redirect(http.StatusFound, "/dashboard")
@@ -987,4 +1367,6 @@ func SetupRoutes(app core.App, cfg *config.Config) {
 
 		return e.Next()
 	})
+
+	return nil
 }
\ No newline at end of file
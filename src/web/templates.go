@@ -0,0 +1,99 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// pageFiles lists the template files of each page; the first one is executed
+var pageFiles = map[string][]string{
+	"login":            {"templates/login.html"},
+	"register":         {"templates/register.html"},
+	"request_status":   {"templates/request_status.html"},
+	"dashboard":        {"templates/base.html", "templates/dashboard.html"},
+	"profile":          {"templates/base.html", "templates/profile.html"},
+	"communities":      {"templates/base.html", "templates/communities.html"},
+	"community":        {"templates/base.html", "templates/community.html"},
+	"local_assignment": {"templates/base.html", "templates/local_assignment.html"},
+	"members":          {"templates/base.html", "templates/members.html"},
+	"admin_requests":   {"templates/admin_requests.html"},
+	"admin_dashboard":  {"templates/admin_dashboard.html"},
+}
+
+// pageCache holds the parsed page templates. With reload set every render parses
+// the page again, so template edits show up without a restart.
+type pageCache struct {
+	fsys   fs.FS
+	reload bool
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// newPageCache parses every page, failing on the first template that does not parse
+func newPageCache(fsys fs.FS, reload bool) (*pageCache, error) {
+	cache := &pageCache{fsys: fsys, reload: reload, pages: make(map[string]*template.Template)}
+	for name := range pageFiles {
+		tmpl, err := cache.parse(name)
+		if err != nil {
+			return nil, err
+		}
+		cache.pages[name] = tmpl
+	}
+	return cache, nil
+}
+
+func (p *pageCache) parse(name string) (*template.Template, error) {
+	files, ok := pageFiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown page %s", name)
+	}
+
+	tmpl, err := template.ParseFS(p.fsys, files...)
+	if err != nil {
+		return nil, fmt.Errorf("page %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func (p *pageCache) get(name string) (*template.Template, error) {
+	if p.reload {
+		tmpl, err := p.parse(name)
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.pages[name] = tmpl
+		p.mu.Unlock()
+		return tmpl, nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	tmpl, ok := p.pages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown page %s", name)
+	}
+	return tmpl, nil
+}
+
+// render writes a page as the HTML response with the given status code
+func (p *pageCache) render(c *core.RequestEvent, status int, name string, data interface{}) error {
+	tmpl, err := p.get(name)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Template error: "+err.Error())
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return c.String(http.StatusInternalServerError, "Template error")
+	}
+
+	return c.HTML(status, buf.String())
+}